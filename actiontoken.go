@@ -0,0 +1,35 @@
+package teamvite
+
+import (
+	"context"
+	"time"
+)
+
+// Actions an ActionToken can be used for
+const (
+	// replying to a game
+	ActionRSVP = "rsvp"
+)
+
+// An ActionToken lets whoever holds it do one thing for one player without
+// logging in, like replying to a game from a reminder. Unlike a session it
+// can't be used anywhere else on the site.
+type ActionToken struct {
+	Token     string    `json:"-"`
+	PlayerID  uint64    `json:"player_id"`
+	GameID    uint64    `json:"game_id"`
+	Action    string    `json:"action"`
+	ExpiresOn time.Time `json:"expires_on"`
+}
+
+// How long the links in a reminder work for
+const ActionTokenLength = time.Hour * 24 * 7
+
+type ActionTokenService interface {
+	// Creates a token letting the player do action on the game.
+	CreateActionToken(ctx context.Context, playerID, gameID uint64, action string) (*ActionToken, error)
+
+	// Retrieves an unexpired token that allows action on the game. Returns
+	// ENOTFOUND otherwise.
+	FindActionToken(ctx context.Context, token string, gameID uint64, action string) (*ActionToken, error)
+}
@@ -0,0 +1,68 @@
+package teamvite
+
+import (
+	"context"
+	"time"
+)
+
+// A message from a manager to everyone on a team
+type Announcement struct {
+	ID         uint64    `json:"id"`
+	TeamID     uint64    `json:"team_id"`
+	PlayerID   uint64    `json:"player_id"`
+	PlayerName string    `json:"player_name"`
+	Body       string    `json:"body"`
+	CreatedOn  time.Time `json:"created_on"`
+
+	// Delivery counts by status
+	Sent    int `json:"sent"`
+	Failed  int `json:"failed"`
+	Pending int `json:"pending"`
+}
+
+// Delivery of an announcement to a player over a single channel
+type Delivery struct {
+	AnnouncementID uint64 `json:"announcement_id"`
+	PlayerID       uint64 `json:"player_id"`
+	PlayerName     string `json:"player_name"`
+	Channel        string `json:"channel"`
+	Status         string `json:"status"`
+
+	// Contact info for sending, not exposed
+	Email string `json:"-"`
+	Phone int    `json:"-"`
+}
+
+// Delivery channels and statuses
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+)
+
+// Longest announcement a manager can send
+const MaxAnnouncementLength = 1000
+
+type AnnouncementService interface {
+	// Returns a team's announcements, newest first
+	FindAnnouncements(ctx context.Context, teamID uint64) ([]*Announcement, error)
+
+	// Saves an announcement from the user in the context along with a pending
+	// delivery for each channel (email and/or SMS) rostered players have
+	// reminders turned on for. Returns EUNAUTHORIZED unless the user manages
+	// the team.
+	CreateAnnouncement(ctx context.Context, a *Announcement) error
+
+	// Returns announcements with deliveries still pending, oldest first, so
+	// sending can resume after a restart
+	FindPendingAnnouncements(ctx context.Context) ([]*Announcement, error)
+
+	// Returns the deliveries for an announcement
+	Deliveries(ctx context.Context, announcementID uint64) ([]*Delivery, error)
+
+	// Records the outcome of sending a delivery
+	UpdateDelivery(ctx context.Context, d *Delivery) error
+}
@@ -12,5 +12,6 @@
 # https://github.com/mattn/go-sqlite3/issues/1164
 CGO_CFLAGS="-D_LARGEFILE64_SOURCE" CGO_ENABLED=1 CC=/usr/local/musl/bin/musl-gcc \
               go build \
+              -tags sqlite_fts5 \
               -ldflags="-extldflags=-static" \
               ./cmd/teamvite
@@ -28,10 +28,17 @@ def main(args):
         con.commit()
 
 
+# teams that have been renamed in teamvite still match their previous names
+TEAM_NAME_MATCH = (
+    "(t.name = ? or t.id in (select team_id from teams_names where name = ?))"
+)
+
+
 def update_or_create_team(cur, division, team):
     row = cur.execute(
-        "select count(*) from teams t join divisions d on division_id = d.id where d.name = ? and t.name = ?",
-        (division, team),
+        "select count(*) from teams t join divisions d on division_id = d.id where d.name = ? and "
+        + TEAM_NAME_MATCH,
+        (division, team, team),
     ).fetchone()
     if row[0] == 1:
         print(f"skipping team: {team}")
@@ -55,8 +62,9 @@ def update_or_create_team(cur, division, team):
         raise Exception(f"unable to find division {division}")
 
     rows = cur.execute(
-        "select d.name, t.id from teams t join divisions d on division_id = d.id where t.name = ?",
-        (team,),
+        "select d.name, t.id from teams t join divisions d on division_id = d.id where "
+        + TEAM_NAME_MATCH,
+        (team, team),
     ).fetchall()
     if len(rows) == 0:
         print(f"creating team: {team}")
@@ -8,12 +8,15 @@ require 'json'
 raise 'APP_URL must be in environment' unless ENV['APP_URL']
 
 APP_URL = ENV['APP_URL']
+# a league administrator's session cookie, so hidden teams can be found
+SESSION = ENV['TEAMVITE_SESSION']
 
 def add_game_for_team(season_id, team_id, game_time, description)
   uri = URI("#{APP_URL}/game")
 
   req = Net::HTTP::Post.new(uri)
   req['Content-Type'] = 'application/json'
+  req['Cookie'] = "teamvite-session=#{SESSION}" if SESSION
 
   g = {
     team_id: team_id,
@@ -51,6 +54,7 @@ def check_get(memo, name, path)
 
     req = Net::HTTP::Get.new(uri)
     req['Content-Type'] = 'application/json'
+  req['Cookie'] = "teamvite-session=#{SESSION}" if SESSION
 
     res = Net::HTTP.start(uri.hostname, uri.port, use_ssl: APP_URL =~ /https/) do |http|
       http.request(req)
@@ -58,7 +62,8 @@ def check_get(memo, name, path)
 
     raise "#{uri} bad: #{res.code} #{res.body}" if res.code != '200'
 
-    objs = JSON.parse(res.body).select { |n| n['name'] == name }
+    # teams that have been renamed still match their previous names
+    objs = JSON.parse(res.body).select { |n| n['name'] == name || (n['previous_names'] || []).include?(name) }
     raise "Too many responses: #{objs}" if objs.length > 1
 
     memo[name] = objs[0]
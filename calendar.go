@@ -0,0 +1,40 @@
+package teamvite
+
+import (
+	"context"
+	"time"
+)
+
+// A secret calendar feed of a player's games. The token in the feed url is
+// the only credential calendar apps send, so it can be rotated or revoked.
+type CalendarFeed struct {
+	ID            uint64     `json:"id"`
+	PlayerID      uint64     `json:"player_id"`
+	TeamID        uint64     `json:"team_id"` // 0 for a feed of all the player's teams
+	TeamName      string     `json:"team_name"`
+	HideDeclined  bool       `json:"hide_declined"` // leave out games the player said no to
+	Token         string     `json:"-"`
+	CreatedOn     time.Time  `json:"created_on"`
+	LastFetchedOn *time.Time `json:"last_fetched_on"`
+}
+
+type CalendarFeedService interface {
+	// Creates a feed of the team for the user in the context, or of all their
+	// teams if teamID is 0. Replaces the token of their existing feed if there
+	// is one.
+	CreateCalendarFeed(ctx context.Context, teamID uint64) (*CalendarFeed, error)
+
+	// Retrieves a feed by its token and records that it was fetched.
+	// Returns ENOTFOUND if the token isn't valid.
+	FindCalendarFeedByToken(ctx context.Context, token string) (*CalendarFeed, error)
+
+	// Returns the feeds of the user in the context.
+	FindCalendarFeeds(ctx context.Context) ([]*CalendarFeed, error)
+
+	// Sets whether a feed of the user in the context shows games they
+	// declined.
+	UpdateCalendarFeed(ctx context.Context, id uint64, hideDeclined bool) (*CalendarFeed, error)
+
+	// Revokes a feed of the user in the context.
+	DeleteCalendarFeed(ctx context.Context, id uint64) error
+}
@@ -0,0 +1,50 @@
+package teamvite
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// How often the server purges expired sessions and tokens
+const CleanupInterval = time.Hour
+
+// How often the server vacuums the database, which locks it while it runs
+const VacuumInterval = time.Hour * 24
+
+// CleanupReport is the number of expired rows removed from each table.
+type CleanupReport map[string]int64
+
+// Total is the number of rows removed from every table.
+func (r CleanupReport) Total() (total int64) {
+	for _, n := range r {
+		total += n
+	}
+	return total
+}
+
+// String lists the counts by table, ex. "action_tokens=3 sessions=12".
+func (r CleanupReport) String() string {
+	tables := make([]string, 0, len(r))
+	for table := range r {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	counts := make([]string, 0, len(tables))
+	for _, table := range tables {
+		counts = append(counts, fmt.Sprintf("%s=%d", table, r[table]))
+	}
+	return strings.Join(counts, " ")
+}
+
+type CleanupService interface {
+	// Deletes expired sessions, login links, signups, password resets, action
+	// tokens and passkey challenges.
+	PurgeExpired(ctx context.Context) (CleanupReport, error)
+
+	// Rebuilds the database to reclaim the space of deleted rows and updates
+	// the query planner's statistics.
+	Vacuum(ctx context.Context) error
+}
@@ -9,11 +9,14 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"time"
 
 	"github.com/benprew/teamvite"
 	http "github.com/benprew/teamvite/http"
 	"github.com/benprew/teamvite/reminders"
+	"github.com/benprew/teamvite/smtp"
 	"github.com/benprew/teamvite/sqlite"
+	"github.com/benprew/teamvite/twilio"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -65,6 +68,15 @@ func main() {
 	}
 	sendRemindersCmd.StringVar(&configPath, "config", teamvite.DefaultConfigPath, "config path")
 
+	cleanupCmd := flag.NewFlagSet("cleanup", flag.ExitOnError)
+	cleanupCmd.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s:\n", cleanupCmd.Name())
+		cleanupCmd.PrintDefaults()
+		os.Exit(1)
+	}
+	cleanupVacuum := cleanupCmd.Bool("vacuum", true, "vacuum and optimize the database after purging")
+	cleanupCmd.StringVar(&configPath, "config", teamvite.DefaultConfigPath, "config path")
+
 	// Instantiate a new type to represent our application.
 	// This type lets us shared setup code with our end-to-end tests.
 	m := newMain(configPath)
@@ -92,6 +104,9 @@ func main() {
 	case "sendreminders":
 		sendRemindersCmd.Parse(os.Args[2:])
 		cmdSendReminders(m)
+	case "cleanup":
+		cleanupCmd.Parse(os.Args[2:])
+		cmdCleanup(m, *cleanupVacuum)
 	default:
 		cmdUsage()
 		os.Exit(1)
@@ -129,6 +144,22 @@ func cmdSendReminders(m *Main) {
 	}
 }
 
+func cmdCleanup(m *Main, vacuum bool) {
+	s := sqlite.NewCleanupService(m.DB)
+	report, err := s.PurgeExpired(context.TODO())
+	if err != nil {
+		log.Fatal("Error purging expired rows: ", err)
+	}
+	fmt.Printf("Purged %d expired rows: %s\n", report.Total(), report)
+	if !vacuum {
+		return
+	}
+	if err := s.Vacuum(context.TODO()); err != nil {
+		log.Fatal("Error vacuuming database: ", err)
+	}
+	fmt.Println("Vacuumed database")
+}
+
 func cmdUsage() {
 	fmt.Print(`
 teamvite - control teamvite server
@@ -137,6 +168,7 @@ commands:
 	serv           - start the server
 	resetpassword  - reset a user's password
 	sendreminders  - send game reminders to teams
+	cleanup        - purge expired sessions and tokens, then vacuum
 
 global options:
 	-[h]elp        - print help and exit
@@ -206,11 +238,26 @@ func (m *Main) Run(ctx context.Context) error {
 	m.HTTPServer.PlayerService = sqlite.NewPlayerService(db)
 	m.HTTPServer.DivisionService = sqlite.NewDivisionService(db)
 	m.HTTPServer.SeasonService = sqlite.NewSeasonService(db)
+	m.HTTPServer.OrganizationService = sqlite.NewOrganizationService(db)
+	m.HTTPServer.SignupService = sqlite.NewSignupService(db)
+	m.HTTPServer.PasswordResetService = sqlite.NewPasswordResetService(db)
+	m.HTTPServer.ActionTokenService = sqlite.NewActionTokenService(db)
+	m.HTTPServer.TwoFactorService = sqlite.NewTwoFactorService(db)
+	m.HTTPServer.PasskeyService = sqlite.NewPasskeyService(db)
+	m.HTTPServer.AnnouncementService = sqlite.NewAnnouncementService(db)
+	m.HTTPServer.InvitationService = sqlite.NewInvitationService(db)
+	m.HTTPServer.JoinRequestService = sqlite.NewJoinRequestService(db)
+	m.HTTPServer.CalendarFeedService = sqlite.NewCalendarFeedService(db)
+	m.HTTPServer.SearchService = sqlite.NewSearchService(db)
 
 	m.HTTPServer.SessionService = sqlite.NewSessionService(db)
+	m.HTTPServer.MailService = smtp.NewMailService(teamvite.CONFIG.SMTP)
+	m.HTTPServer.SMSService = twilio.NewSMSService(teamvite.CONFIG.SMS)
 
 	fmt.Printf("Starting teamvite server on %s\n", m.HTTPServer.Addr)
 	go func() { m.HTTPServer.Open() }()
+	go janitor(ctx, sqlite.NewCleanupService(db))
+	go m.HTTPServer.ResumeAnnouncements(ctx)
 
 	return nil
 }
@@ -221,3 +268,33 @@ func (m *Main) Close() error {
 	}
 	return nil
 }
+
+// janitor purges expired sessions and tokens every CleanupInterval, and
+// vacuums every VacuumInterval, until ctx is done.
+func janitor(ctx context.Context, s teamvite.CleanupService) {
+	lastVacuum := time.Now()
+	ticker := time.NewTicker(teamvite.CleanupInterval)
+	defer ticker.Stop()
+	for {
+		report, err := s.PurgeExpired(ctx)
+		if err != nil {
+			log.Printf("[ERROR] janitor: purging expired rows: %s\n", err)
+		} else {
+			log.Printf("[INFO] janitor: purged %d expired rows [%s]\n", report.Total(), report)
+		}
+		if time.Since(lastVacuum) >= teamvite.VacuumInterval {
+			if err := s.Vacuum(ctx); err != nil {
+				log.Printf("[ERROR] janitor: vacuuming: %s\n", err)
+			} else {
+				log.Println("[INFO] janitor: vacuumed database")
+			}
+			lastVacuum = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -13,6 +13,9 @@ type Config struct {
 	Servername string `json:"servername"` // teamvite.com, teamvitedev.com
 	SMTP       SMTPConfig
 	SMS        SMSConfig
+
+	// Team managers and league admins must use two-factor authentication
+	RequireTwoFactor bool `json:"require_two_factor"`
 }
 
 type SMTPConfig struct {
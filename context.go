@@ -15,6 +15,9 @@ const (
 	// Stores the current logged in player in the context.
 	userContextKey = contextKey(iota + 1)
 
+	// Stores the logged in player's session
+	sessionContextKey
+
 	// Stores the "flash" in the context. This is a term used in web development
 	// for a message that is passed from one request to the next for informational
 	// purposes. This could be moved into the "http" package as it is only HTTP
@@ -31,6 +34,11 @@ const (
 	playerKey
 	teamKey
 	gameKey
+	divisionKey
+	seasonKey
+
+	// The organization (league) the request is for
+	organizationKey
 )
 
 // NewContextWithUser returns a new context with the given player.
@@ -53,6 +61,17 @@ func UserIDFromContext(ctx context.Context) uint64 {
 	return 0
 }
 
+// NewContextWithSession returns a new context with the user's session.
+func NewContextWithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionContextKey, session)
+}
+
+// SessionFromContext returns the logged in player's session, or nil.
+func SessionFromContext(ctx context.Context) *Session {
+	session, _ := ctx.Value(sessionContextKey).(*Session)
+	return session
+}
+
 // NewContextWithFlash returns a new context with the given flash value.
 func NewContextWithFlash(ctx context.Context, v string) context.Context {
 	return context.WithValue(ctx, flashContextKey, v)
@@ -85,6 +104,13 @@ func NewContextWithGame(ctx context.Context, template string, game *Game) contex
 }
 
 func NewContextWithDivision(ctx context.Context, template string, division *Division) context.Context {
+	ctx = context.WithValue(ctx, divisionKey, division)
+	ctx = context.WithValue(ctx, templateKey, template)
+	return ctx
+}
+
+func NewContextWithSeason(ctx context.Context, template string, season *Season) context.Context {
+	ctx = context.WithValue(ctx, seasonKey, season)
 	ctx = context.WithValue(ctx, templateKey, template)
 	return ctx
 }
@@ -104,7 +130,36 @@ func GameFromContext(ctx context.Context) *Game {
 	return game
 }
 
+func DivisionFromContext(ctx context.Context) *Division {
+	division, _ := ctx.Value(divisionKey).(*Division)
+	return division
+}
+
 func PlayerFromContext(ctx context.Context) *Player {
 	player, _ := ctx.Value(playerKey).(*Player)
 	return player
 }
+
+func SeasonFromContext(ctx context.Context) *Season {
+	season, _ := ctx.Value(seasonKey).(*Season)
+	return season
+}
+
+func NewContextWithOrganization(ctx context.Context, org *Organization) context.Context {
+	return context.WithValue(ctx, organizationKey, org)
+}
+
+func OrganizationFromContext(ctx context.Context) *Organization {
+	org, _ := ctx.Value(organizationKey).(*Organization)
+	return org
+}
+
+// OrganizationIDFromContext returns the ID of the request's organization, or
+// 0 outside of a request (ex. sending reminders), which sees every
+// organization.
+func OrganizationIDFromContext(ctx context.Context) uint64 {
+	if org := OrganizationFromContext(ctx); org != nil {
+		return org.ID
+	}
+	return 0
+}
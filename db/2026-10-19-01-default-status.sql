@@ -0,0 +1,2 @@
+-- Default RSVP response per player per team: '' (ask every game), 'Y' or 'N'
+ALTER TABLE players_teams ADD COLUMN default_status varchar(32) NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+CREATE TABLE games_messages (
+    id integer PRIMARY KEY autoincrement,
+    game_id integer NOT NULL,
+    player_id integer NOT NULL,
+    body varchar(1024) NOT NULL,
+    created_on datetime NOT NULL,
+    FOREIGN KEY (game_id) REFERENCES games (id),
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
+
+CREATE INDEX games_messages_game_id ON games_messages (game_id);
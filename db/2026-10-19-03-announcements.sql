@@ -0,0 +1,20 @@
+CREATE TABLE announcements (
+    id integer PRIMARY KEY autoincrement,
+    team_id integer NOT NULL,
+    player_id integer NOT NULL,
+    body varchar(1024) NOT NULL,
+    created_on datetime NOT NULL,
+    FOREIGN KEY (team_id) REFERENCES teams (id),
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
+
+CREATE TABLE announcements_deliveries (
+    announcement_id integer NOT NULL,
+    player_id integer NOT NULL,
+    channel varchar(16) NOT NULL, -- email or sms
+    status varchar(16) NOT NULL DEFAULT 'pending', -- pending, sent or failed
+    updated_on datetime,
+    PRIMARY KEY (announcement_id, player_id, channel),
+    FOREIGN KEY (announcement_id) REFERENCES announcements (id),
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
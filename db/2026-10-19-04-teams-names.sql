@@ -0,0 +1,8 @@
+-- previous names of renamed teams, so imports using an old name still match
+CREATE TABLE teams_names (
+    team_id integer NOT NULL,
+    name varchar(64) NOT NULL,
+    changed_on datetime NOT NULL,
+    PRIMARY KEY (team_id, name),
+    FOREIGN KEY (team_id) REFERENCES teams (id)
+);
@@ -0,0 +1,17 @@
+ALTER TABLE teams ADD COLUMN owner_id integer REFERENCES players (id);
+
+-- the longest-standing manager becomes the owner of existing teams
+UPDATE teams SET owner_id = (
+    SELECT min(player_id) FROM players_teams WHERE team_id = teams.id AND is_manager
+);
+
+-- pending ownership transfers, waiting on the new owner to accept
+CREATE TABLE teams_transfers (
+    team_id integer NOT NULL PRIMARY KEY,
+    from_player_id integer NOT NULL,
+    to_player_id integer NOT NULL,
+    created_on datetime NOT NULL,
+    FOREIGN KEY (team_id) REFERENCES teams (id),
+    FOREIGN KEY (from_player_id) REFERENCES players (id),
+    FOREIGN KEY (to_player_id) REFERENCES players (id)
+);
@@ -0,0 +1,15 @@
+CREATE TABLE invitations (
+    id integer PRIMARY KEY autoincrement,
+    team_id integer NOT NULL,
+    email varchar(128) NOT NULL,
+    name varchar(64) NOT NULL DEFAULT '',
+    token varchar(128) NOT NULL UNIQUE,
+    invited_by integer NOT NULL,
+    status varchar(16) NOT NULL DEFAULT 'pending', -- pending, accepted, declined or revoked
+    created_on datetime NOT NULL,
+    expires_on datetime NOT NULL,
+    FOREIGN KEY (team_id) REFERENCES teams (id),
+    FOREIGN KEY (invited_by) REFERENCES players (id)
+);
+
+CREATE INDEX invitations_team_id ON invitations (team_id);
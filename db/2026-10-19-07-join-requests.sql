@@ -0,0 +1,13 @@
+
+CREATE TABLE join_requests (
+    id integer PRIMARY KEY autoincrement,
+    team_id integer NOT NULL,
+    player_id integer NOT NULL,
+    message text NOT NULL DEFAULT '',
+    status varchar(16) NOT NULL DEFAULT 'pending', -- pending, approved or rejected
+    created_on datetime NOT NULL,
+    FOREIGN KEY (team_id) REFERENCES teams (id),
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
+
+CREATE INDEX join_requests_team_id ON join_requests (team_id);
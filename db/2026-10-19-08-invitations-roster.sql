@@ -0,0 +1,2 @@
+ALTER TABLE invitations ADD COLUMN phone int8 NOT NULL DEFAULT 0;
+ALTER TABLE invitations ADD COLUMN is_manager boolean NOT NULL DEFAULT 0;
@@ -0,0 +1,22 @@
+ALTER TABLE teams ADD COLUMN season_id integer REFERENCES seasons (id);
+
+-- roster of each season a team has played, players_teams is the current roster
+CREATE TABLE players_teams_seasons (
+    player_id integer NOT NULL,
+    team_id integer NOT NULL,
+    season_id integer NOT NULL,
+    status varchar(16) NOT NULL DEFAULT 'active', -- active, pending or declined
+    PRIMARY KEY (team_id, season_id, player_id),
+    FOREIGN KEY (team_id) REFERENCES teams (id),
+    FOREIGN KEY (player_id) REFERENCES players (id),
+    FOREIGN KEY (season_id) REFERENCES seasons (id)
+);
+
+-- the current roster is for the latest season the team has games in
+UPDATE teams SET season_id = (SELECT max(season_id) FROM games WHERE games.team_id = teams.id);
+
+INSERT INTO players_teams_seasons (player_id, team_id, season_id, status)
+SELECT pt.player_id, pt.team_id, t.season_id, 'active'
+FROM players_teams pt
+JOIN teams t ON pt.team_id = t.id
+WHERE t.season_id IS NOT NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE players_teams ADD COLUMN jersey varchar(3) NOT NULL DEFAULT '';
+ALTER TABLE players_teams ADD COLUMN positions varchar(64) NOT NULL DEFAULT '';
+ALTER TABLE players_teams ADD COLUMN roster_status varchar(16) NOT NULL DEFAULT 'active'; -- active or injured
+
+CREATE UNIQUE INDEX players_teams_jersey ON players_teams (team_id, jersey) WHERE jersey != '';
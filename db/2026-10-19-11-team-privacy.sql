@@ -0,0 +1 @@
+ALTER TABLE teams ADD COLUMN privacy varchar(16) NOT NULL DEFAULT 'public'; -- public, members or hidden
@@ -0,0 +1,11 @@
+CREATE TABLE calendar_feeds (
+    id integer PRIMARY KEY autoincrement,
+    player_id integer NOT NULL,
+    team_id integer NOT NULL,
+    token varchar(128) NOT NULL UNIQUE,
+    created_on datetime NOT NULL,
+    last_fetched_on datetime,
+    UNIQUE (player_id, team_id),
+    FOREIGN KEY (player_id) REFERENCES players (id),
+    FOREIGN KEY (team_id) REFERENCES teams (id)
+);
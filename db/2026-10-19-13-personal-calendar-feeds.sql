@@ -0,0 +1,18 @@
+-- personal feeds don't have a team, sqlite can't drop NOT NULL so rebuild the table
+CREATE TABLE calendar_feeds_new (
+    id integer PRIMARY KEY autoincrement,
+    player_id integer NOT NULL,
+    team_id integer, -- null for a feed of all the player's teams
+    hide_declined boolean NOT NULL DEFAULT 0,
+    token varchar(128) NOT NULL UNIQUE,
+    created_on datetime NOT NULL,
+    last_fetched_on datetime,
+    UNIQUE (player_id, team_id),
+    FOREIGN KEY (player_id) REFERENCES players (id),
+    FOREIGN KEY (team_id) REFERENCES teams (id)
+);
+
+INSERT INTO calendar_feeds_new (id, player_id, team_id, token, created_on, last_fetched_on)
+SELECT id, player_id, team_id, token, created_on, last_fetched_on FROM calendar_feeds;
+DROP TABLE calendar_feeds;
+ALTER TABLE calendar_feeds_new RENAME TO calendar_feeds;
@@ -0,0 +1,2 @@
+ALTER TABLE players ADD COLUMN is_admin boolean NOT NULL DEFAULT 0; -- league administrator
+ALTER TABLE divisions ADD COLUMN archived boolean NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE seasons ADD COLUMN start_on date;
+ALTER TABLE seasons ADD COLUMN end_on date;
+ALTER TABLE seasons ADD COLUMN closed boolean NOT NULL DEFAULT 0;
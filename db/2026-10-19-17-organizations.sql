@@ -0,0 +1,63 @@
+-- existing divisions, seasons and administrators belong to the first league.
+-- teams, games and rosters reference the rebuilt divisions and seasons, so
+-- foreign keys are off for the rebuild (https://www.sqlite.org/lang_altertable.html#otheralter).
+-- The rebuild drops the divisions search triggers, apply search.sql again
+-- afterwards if search is set up.
+PRAGMA foreign_keys = OFF;
+BEGIN;
+
+CREATE TABLE organizations (
+    id integer PRIMARY KEY autoincrement,
+    name varchar(64) NOT NULL,
+    host varchar(128) NOT NULL UNIQUE, -- organization 1 gets requests for other hosts, and is served from the configured servername when blank
+    sender varchar(128) NOT NULL DEFAULT '', -- address mail is sent from
+    sender_name varchar(64) NOT NULL DEFAULT ''
+);
+
+INSERT INTO organizations (id, name, host) VALUES (1, 'Teamvite', '');
+
+CREATE TABLE organization_admins (
+    organization_id integer NOT NULL,
+    player_id integer NOT NULL,
+    PRIMARY KEY (organization_id, player_id),
+    FOREIGN KEY (organization_id) REFERENCES organizations (id),
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
+
+INSERT INTO organization_admins (organization_id, player_id) SELECT 1, id FROM players WHERE is_admin;
+ALTER TABLE players DROP COLUMN is_admin;
+
+-- names are unique within an organization, sqlite can't drop the old UNIQUE
+-- constraints so rebuild the tables
+CREATE TABLE divisions_new (
+    id integer NOT NULL PRIMARY KEY autoincrement,
+    organization_id integer NOT NULL DEFAULT 1,
+    name varchar(64) NOT NULL,
+    archived boolean NOT NULL DEFAULT 0,
+    UNIQUE (organization_id, name),
+    FOREIGN KEY (organization_id) REFERENCES organizations (id)
+);
+
+INSERT INTO divisions_new (id, name, archived) SELECT id, name, archived FROM divisions;
+DROP TABLE divisions;
+ALTER TABLE divisions_new RENAME TO divisions;
+
+CREATE TABLE seasons_new (
+    id integer PRIMARY KEY autoincrement,
+    organization_id integer NOT NULL DEFAULT 1,
+    name string NOT NULL,
+    start_on date,
+    end_on date,
+    closed boolean NOT NULL DEFAULT 0,
+    UNIQUE (organization_id, name),
+    FOREIGN KEY (organization_id) REFERENCES organizations (id)
+);
+
+INSERT INTO seasons_new (id, name, start_on, end_on, closed) SELECT id, name, start_on, end_on, closed FROM seasons;
+DROP TABLE seasons;
+ALTER TABLE seasons_new RENAME TO seasons;
+
+-- must not return any rows
+PRAGMA foreign_key_check;
+COMMIT;
+PRAGMA foreign_keys = ON;
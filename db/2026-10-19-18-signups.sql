@@ -0,0 +1,9 @@
+-- accounts waiting for their email to be verified
+CREATE TABLE signups (
+    token varchar(128) NOT NULL PRIMARY KEY,
+    name varchar(64) NOT NULL,
+    email varchar(128) NOT NULL,
+    password varchar(1024) NOT NULL, -- bcrypt hash
+    phone int8 NOT NULL DEFAULT 0,
+    expires_on datetime NOT NULL
+);
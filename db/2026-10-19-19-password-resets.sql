@@ -0,0 +1,7 @@
+-- emailed links to set a new password, deleted once used
+CREATE TABLE password_resets (
+    token varchar(128) NOT NULL PRIMARY KEY,
+    player_id integer NOT NULL,
+    expires_on datetime NOT NULL,
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
@@ -0,0 +1,2 @@
+-- emailed single-use links, exchanged for a session
+ALTER TABLE sessions ADD COLUMN login_token boolean NOT NULL DEFAULT 0;
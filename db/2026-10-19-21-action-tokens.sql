@@ -0,0 +1,10 @@
+-- links in reminders that can only reply to one game for one player
+CREATE TABLE action_tokens (
+    token varchar(128) NOT NULL PRIMARY KEY,
+    player_id integer NOT NULL,
+    game_id integer NOT NULL,
+    action varchar(32) NOT NULL,
+    expires_on datetime NOT NULL,
+    FOREIGN KEY (player_id) REFERENCES players (id),
+    FOREIGN KEY (game_id) REFERENCES games (id)
+);
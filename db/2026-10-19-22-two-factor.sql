@@ -0,0 +1,19 @@
+-- sessions logged in with a two-factor code
+ALTER TABLE sessions ADD COLUMN two_factor boolean NOT NULL DEFAULT 0;
+
+-- authenticator app secrets, needed to log in once enabled
+CREATE TABLE two_factors (
+    player_id integer NOT NULL PRIMARY KEY,
+    secret varchar(64) NOT NULL,
+    enabled boolean NOT NULL DEFAULT 0,
+    last_step integer NOT NULL DEFAULT 0, -- of the last code used, so codes can't be replayed
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
+
+-- single-use codes for when the authenticator app is lost, stored hashed
+CREATE TABLE recovery_codes (
+    player_id integer NOT NULL,
+    code_hash varchar(64) NOT NULL,
+    PRIMARY KEY (player_id, code_hash),
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
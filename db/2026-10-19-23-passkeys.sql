@@ -0,0 +1,19 @@
+-- WebAuthn credentials players can log in with, one per device
+CREATE TABLE passkeys (
+    id integer PRIMARY KEY autoincrement,
+    player_id integer NOT NULL,
+    name varchar(255) NOT NULL DEFAULT '',
+    credential_id blob NOT NULL UNIQUE,
+    public_key blob NOT NULL,
+    sign_count integer NOT NULL DEFAULT 0,
+    created_on datetime NOT NULL,
+    last_used_on datetime,
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
+
+-- challenges of passkey registrations and logins in progress, used once
+CREATE TABLE passkey_challenges (
+    challenge varchar(128) NOT NULL PRIMARY KEY,
+    player_id integer NOT NULL DEFAULT 0, -- 0 when logging in
+    expires_on datetime NOT NULL
+);
@@ -0,0 +1,3 @@
+-- when sessions were made and last used, for the sessions page
+ALTER TABLE sessions ADD COLUMN created_on datetime;
+ALTER TABLE sessions ADD COLUMN last_seen_on datetime;
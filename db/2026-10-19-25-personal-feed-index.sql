@@ -0,0 +1,7 @@
+-- UNIQUE (player_id, team_id) allows any number of null teams, keep each
+-- player's newest personal feed
+DELETE FROM calendar_feeds
+WHERE team_id IS NULL
+  AND id NOT IN (SELECT max(id) FROM calendar_feeds WHERE team_id IS NULL GROUP BY player_id);
+
+CREATE UNIQUE INDEX calendar_feeds_personal ON calendar_feeds (player_id) WHERE team_id IS NULL;
@@ -0,0 +1,22 @@
+-- expires_on of the expiring tables was written as text in a few formats,
+-- store it in unix seconds like the sessions default so expired rows can be
+-- found in sql. Rows that can't be parsed are deleted.
+UPDATE sessions SET expires_on = CAST(strftime('%s', expires_on) AS INTEGER)
+WHERE typeof(expires_on) = 'text' AND strftime('%s', expires_on) IS NOT NULL;
+DELETE FROM sessions WHERE typeof(expires_on) != 'integer';
+
+UPDATE signups SET expires_on = CAST(strftime('%s', expires_on) AS INTEGER)
+WHERE typeof(expires_on) = 'text' AND strftime('%s', expires_on) IS NOT NULL;
+DELETE FROM signups WHERE typeof(expires_on) != 'integer';
+
+UPDATE password_resets SET expires_on = CAST(strftime('%s', expires_on) AS INTEGER)
+WHERE typeof(expires_on) = 'text' AND strftime('%s', expires_on) IS NOT NULL;
+DELETE FROM password_resets WHERE typeof(expires_on) != 'integer';
+
+UPDATE action_tokens SET expires_on = CAST(strftime('%s', expires_on) AS INTEGER)
+WHERE typeof(expires_on) = 'text' AND strftime('%s', expires_on) IS NOT NULL;
+DELETE FROM action_tokens WHERE typeof(expires_on) != 'integer';
+
+UPDATE passkey_challenges SET expires_on = CAST(strftime('%s', expires_on) AS INTEGER)
+WHERE typeof(expires_on) = 'text' AND strftime('%s', expires_on) IS NOT NULL;
+DELETE FROM passkey_challenges WHERE typeof(expires_on) != 'integer';
@@ -6,17 +6,75 @@ CREATE TABLE players (
     phone int8 NOT NULL DEFAULT 0
 );
 
+-- accounts waiting for their email to be verified
+CREATE TABLE signups (
+    token varchar(128) NOT NULL PRIMARY KEY,
+    name varchar(64) NOT NULL,
+    email varchar(128) NOT NULL,
+    password varchar(1024) NOT NULL, -- bcrypt hash
+    phone int8 NOT NULL DEFAULT 0,
+    expires_on datetime NOT NULL -- unix seconds
+);
+
+-- leagues, each with its own divisions, seasons and administrators
+CREATE TABLE organizations (
+    id integer PRIMARY KEY autoincrement,
+    name varchar(64) NOT NULL,
+    host varchar(128) NOT NULL UNIQUE, -- organization 1 gets requests for other hosts, and is served from the configured servername when blank
+    sender varchar(128) NOT NULL DEFAULT '', -- address mail is sent from
+    sender_name varchar(64) NOT NULL DEFAULT ''
+);
+
+INSERT INTO organizations (id, name, host) VALUES (1, 'Teamvite', '');
+
+CREATE TABLE organization_admins (
+    organization_id integer NOT NULL,
+    player_id integer NOT NULL,
+    PRIMARY KEY (organization_id, player_id),
+    FOREIGN KEY (organization_id) REFERENCES organizations (id),
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
+
 CREATE TABLE teams (
     id integer PRIMARY KEY autoincrement,
     name varchar(64) NOT NULL DEFAULT '',
     division_id integer NOT NULL,
+    owner_id integer,
+    season_id integer, -- season of the current roster
+    privacy varchar(16) NOT NULL DEFAULT 'public', -- public, members or hidden
     UNIQUE (name, division_id),
-    FOREIGN KEY (division_id) REFERENCES divisions (id)
+    FOREIGN KEY (division_id) REFERENCES divisions (id),
+    FOREIGN KEY (owner_id) REFERENCES players (id),
+    FOREIGN KEY (season_id) REFERENCES seasons (id)
+);
+
+-- pending ownership transfers, waiting on the new owner to accept
+CREATE TABLE teams_transfers (
+    team_id integer NOT NULL PRIMARY KEY,
+    from_player_id integer NOT NULL,
+    to_player_id integer NOT NULL,
+    created_on datetime NOT NULL,
+    FOREIGN KEY (team_id) REFERENCES teams (id),
+    FOREIGN KEY (from_player_id) REFERENCES players (id),
+    FOREIGN KEY (to_player_id) REFERENCES players (id)
+);
+
+-- previous names of renamed teams, so imports using an old name still match
+CREATE TABLE teams_names (
+    team_id integer NOT NULL,
+    name varchar(64) NOT NULL,
+    changed_on datetime NOT NULL,
+    PRIMARY KEY (team_id, name),
+    FOREIGN KEY (team_id) REFERENCES teams (id)
 );
 
 CREATE TABLE divisions (
     id integer NOT NULL PRIMARY KEY autoincrement,
-    name varchar(64) NOT NULL UNIQUE
+    organization_id integer NOT NULL DEFAULT 1,
+    name varchar(64) NOT NULL,
+    archived boolean NOT NULL DEFAULT 0,
+    UNIQUE (organization_id, name),
+    FOREIGN KEY (organization_id) REFERENCES organizations (id)
 );
 
 CREATE TABLE games (
@@ -36,11 +94,29 @@ CREATE TABLE players_teams (
     is_manager boolean NOT NULL DEFAULT FALSE,
     remind_email boolean NOT NULL DEFAULT TRUE,
     remind_sms boolean NOT NULL DEFAULT FALSE,
+    default_status varchar(32) NOT NULL DEFAULT '',
+    jersey varchar(3) NOT NULL DEFAULT '',
+    positions varchar(64) NOT NULL DEFAULT '',
+    roster_status varchar(16) NOT NULL DEFAULT 'active', -- active or injured
     PRIMARY KEY (team_id, player_id),
     FOREIGN KEY (team_id) REFERENCES teams (id),
     FOREIGN KEY (player_id) REFERENCES players (id)
 );
 
+CREATE UNIQUE INDEX players_teams_jersey ON players_teams (team_id, jersey) WHERE jersey != '';
+
+-- roster of each season a team has played, players_teams is the current roster
+CREATE TABLE players_teams_seasons (
+    player_id integer NOT NULL,
+    team_id integer NOT NULL,
+    season_id integer NOT NULL,
+    status varchar(16) NOT NULL DEFAULT 'active', -- active, pending or declined
+    PRIMARY KEY (team_id, season_id, player_id),
+    FOREIGN KEY (team_id) REFERENCES teams (id),
+    FOREIGN KEY (player_id) REFERENCES players (id),
+    FOREIGN KEY (season_id) REFERENCES seasons (id)
+);
+
 CREATE TABLE players_games (
     player_id integer NOT NULL,
     game_id integer NOT NULL,
@@ -51,15 +127,163 @@ CREATE TABLE players_games (
     FOREIGN KEY (game_id) REFERENCES games (id)
 );
 
+CREATE TABLE games_messages (
+    id integer PRIMARY KEY autoincrement,
+    game_id integer NOT NULL,
+    player_id integer NOT NULL,
+    body varchar(1024) NOT NULL,
+    created_on datetime NOT NULL,
+    FOREIGN KEY (game_id) REFERENCES games (id),
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
+
+CREATE INDEX games_messages_game_id ON games_messages (game_id);
+
 CREATE TABLE seasons (
     id integer PRIMARY KEY autoincrement,
-    name string NOT NULL UNIQUE
+    organization_id integer NOT NULL DEFAULT 1,
+    name string NOT NULL,
+    start_on date,
+    end_on date,
+    closed boolean NOT NULL DEFAULT 0,
+    UNIQUE (organization_id, name),
+    FOREIGN KEY (organization_id) REFERENCES organizations (id)
 );
 
 CREATE TABLE sessions (
     id varchar(128) NOT NULL PRIMARY KEY,
     player_id NOT NULL,
     ip varchar,
-    expires_on datetime NOT NULL DEFAULT 2556144000, -- 1/1/2051
+    expires_on datetime NOT NULL DEFAULT 2556144000, -- unix seconds, 1/1/2051
+    login_token boolean NOT NULL DEFAULT 0, -- emailed single-use link, exchanged for a session
+    two_factor boolean NOT NULL DEFAULT 0, -- logged in with a two-factor code
+    created_on datetime,
+    last_seen_on datetime,
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
+
+-- emailed links to set a new password, deleted once used
+CREATE TABLE password_resets (
+    token varchar(128) NOT NULL PRIMARY KEY,
+    player_id integer NOT NULL,
+    expires_on datetime NOT NULL, -- unix seconds
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
+
+-- authenticator app secrets, needed to log in once enabled
+CREATE TABLE two_factors (
+    player_id integer NOT NULL PRIMARY KEY,
+    secret varchar(64) NOT NULL,
+    enabled boolean NOT NULL DEFAULT 0,
+    last_step integer NOT NULL DEFAULT 0, -- of the last code used, so codes can't be replayed
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
+
+-- single-use codes for when the authenticator app is lost, stored hashed
+CREATE TABLE recovery_codes (
+    player_id integer NOT NULL,
+    code_hash varchar(64) NOT NULL,
+    PRIMARY KEY (player_id, code_hash),
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
+
+-- WebAuthn credentials players can log in with, one per device
+CREATE TABLE passkeys (
+    id integer PRIMARY KEY autoincrement,
+    player_id integer NOT NULL,
+    name varchar(255) NOT NULL DEFAULT '',
+    credential_id blob NOT NULL UNIQUE,
+    public_key blob NOT NULL,
+    sign_count integer NOT NULL DEFAULT 0,
+    created_on datetime NOT NULL,
+    last_used_on datetime,
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
+
+-- challenges of passkey registrations and logins in progress, used once
+CREATE TABLE passkey_challenges (
+    challenge varchar(128) NOT NULL PRIMARY KEY,
+    player_id integer NOT NULL DEFAULT 0, -- 0 when logging in
+    expires_on datetime NOT NULL -- unix seconds
+);
+
+-- links in reminders that can only reply to one game for one player
+CREATE TABLE action_tokens (
+    token varchar(128) NOT NULL PRIMARY KEY,
+    player_id integer NOT NULL,
+    game_id integer NOT NULL,
+    action varchar(32) NOT NULL,
+    expires_on datetime NOT NULL, -- unix seconds
+    FOREIGN KEY (player_id) REFERENCES players (id),
+    FOREIGN KEY (game_id) REFERENCES games (id)
+);
+
+CREATE TABLE announcements (
+    id integer PRIMARY KEY autoincrement,
+    team_id integer NOT NULL,
+    player_id integer NOT NULL,
+    body varchar(1024) NOT NULL,
+    created_on datetime NOT NULL,
+    FOREIGN KEY (team_id) REFERENCES teams (id),
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
+
+CREATE TABLE announcements_deliveries (
+    announcement_id integer NOT NULL,
+    player_id integer NOT NULL,
+    channel varchar(16) NOT NULL, -- email or sms
+    status varchar(16) NOT NULL DEFAULT 'pending', -- pending, sent or failed
+    updated_on datetime,
+    PRIMARY KEY (announcement_id, player_id, channel),
+    FOREIGN KEY (announcement_id) REFERENCES announcements (id),
     FOREIGN KEY (player_id) REFERENCES players (id)
 );
+
+CREATE TABLE invitations (
+    id integer PRIMARY KEY autoincrement,
+    team_id integer NOT NULL,
+    email varchar(128) NOT NULL,
+    name varchar(64) NOT NULL DEFAULT '',
+    phone int8 NOT NULL DEFAULT 0,
+    is_manager boolean NOT NULL DEFAULT 0,
+    token varchar(128) NOT NULL UNIQUE,
+    invited_by integer NOT NULL,
+    status varchar(16) NOT NULL DEFAULT 'pending', -- pending, accepted, declined or revoked
+    created_on datetime NOT NULL,
+    expires_on datetime NOT NULL,
+    FOREIGN KEY (team_id) REFERENCES teams (id),
+    FOREIGN KEY (invited_by) REFERENCES players (id)
+);
+
+CREATE INDEX invitations_team_id ON invitations (team_id);
+
+CREATE TABLE join_requests (
+    id integer PRIMARY KEY autoincrement,
+    team_id integer NOT NULL,
+    player_id integer NOT NULL,
+    message text NOT NULL DEFAULT '',
+    status varchar(16) NOT NULL DEFAULT 'pending', -- pending, approved or rejected
+    created_on datetime NOT NULL,
+    FOREIGN KEY (team_id) REFERENCES teams (id),
+    FOREIGN KEY (player_id) REFERENCES players (id)
+);
+
+CREATE INDEX join_requests_team_id ON join_requests (team_id);
+
+CREATE TABLE calendar_feeds (
+    id integer PRIMARY KEY autoincrement,
+    player_id integer NOT NULL,
+    team_id integer, -- null for a feed of all the player's teams
+    hide_declined boolean NOT NULL DEFAULT 0,
+    token varchar(128) NOT NULL UNIQUE,
+    created_on datetime NOT NULL,
+    last_fetched_on datetime,
+    UNIQUE (player_id, team_id),
+    FOREIGN KEY (player_id) REFERENCES players (id),
+    FOREIGN KEY (team_id) REFERENCES teams (id)
+);
+
+-- UNIQUE (player_id, team_id) allows any number of null teams
+CREATE UNIQUE INDEX calendar_feeds_personal ON calendar_feeds (player_id) WHERE team_id IS NULL;
+
+-- the search index is in search.sql
@@ -0,0 +1,65 @@
+-- Full text search of teams, divisions and players. Needs sqlite built with
+-- FTS5 (the sqlite_fts5 build tag), so it's kept out of create.sql. Run after
+-- create.sql, it also indexes existing rows. It can be run again, e.g. after
+-- a migration rebuilds one of the tables, to restore the triggers and reindex.
+CREATE VIRTUAL TABLE IF NOT EXISTS search_index USING fts5 (
+    kind UNINDEXED, -- team, division or player
+    item_id UNINDEXED,
+    name,
+    compact, -- name without punctuation, so "F.C." matches "FC"
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS teams_search_insert AFTER INSERT ON teams BEGIN
+    INSERT INTO search_index (kind, item_id, name, compact)
+    VALUES ('team', new.id, new.name, replace(replace(replace(new.name, '.', ''), '''', ''), '-', ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS teams_search_update AFTER UPDATE OF name ON teams BEGIN
+    UPDATE search_index SET name = new.name, compact = replace(replace(replace(new.name, '.', ''), '''', ''), '-', '')
+    WHERE kind = 'team' AND item_id = new.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS teams_search_delete AFTER DELETE ON teams BEGIN
+    DELETE FROM search_index WHERE kind = 'team' AND item_id = old.id;
+END;
+
+DELETE FROM search_index WHERE kind = 'team';
+INSERT INTO search_index (kind, item_id, name, compact)
+SELECT 'team', id, name, replace(replace(replace(name, '.', ''), '''', ''), '-', '') FROM teams;
+
+CREATE TRIGGER IF NOT EXISTS divisions_search_insert AFTER INSERT ON divisions BEGIN
+    INSERT INTO search_index (kind, item_id, name, compact)
+    VALUES ('division', new.id, new.name, replace(replace(replace(new.name, '.', ''), '''', ''), '-', ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS divisions_search_update AFTER UPDATE OF name ON divisions BEGIN
+    UPDATE search_index SET name = new.name, compact = replace(replace(replace(new.name, '.', ''), '''', ''), '-', '')
+    WHERE kind = 'division' AND item_id = new.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS divisions_search_delete AFTER DELETE ON divisions BEGIN
+    DELETE FROM search_index WHERE kind = 'division' AND item_id = old.id;
+END;
+
+DELETE FROM search_index WHERE kind = 'division';
+INSERT INTO search_index (kind, item_id, name, compact)
+SELECT 'division', id, name, replace(replace(replace(name, '.', ''), '''', ''), '-', '') FROM divisions;
+
+CREATE TRIGGER IF NOT EXISTS players_search_insert AFTER INSERT ON players BEGIN
+    INSERT INTO search_index (kind, item_id, name, compact)
+    VALUES ('player', new.id, new.name, replace(replace(replace(new.name, '.', ''), '''', ''), '-', ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS players_search_update AFTER UPDATE OF name ON players BEGIN
+    UPDATE search_index SET name = new.name, compact = replace(replace(replace(new.name, '.', ''), '''', ''), '-', '')
+    WHERE kind = 'player' AND item_id = new.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS players_search_delete AFTER DELETE ON players BEGIN
+    DELETE FROM search_index WHERE kind = 'player' AND item_id = old.id;
+END;
+
+DELETE FROM search_index WHERE kind = 'player';
+INSERT INTO search_index (kind, item_id, name, compact)
+SELECT 'player', id, name, replace(replace(replace(name, '.', ''), '''', ''), '-', '') FROM players;
@@ -3,8 +3,12 @@ package teamvite
 import "context"
 
 type Division struct {
-	ID   uint64 `json:"id"`
-	Name string `json:"name"`
+	ID             uint64 `json:"id"`
+	OrganizationID uint64 `json:"organization_id"`
+	Name           string `json:"name"`
+	// Archived divisions are kept for old teams and games but aren't offered
+	// for new ones.
+	Archived bool `json:"archived"`
 }
 
 func (d *Division) ItemID() uint64 {
@@ -20,13 +24,29 @@ type DivisionService interface {
 
 	// Retrieves a list of Divisions based on a filter.
 	FindDivisions(ctx context.Context, filter DivisionFilter) ([]*Division, int, error)
+
+	// Creates a new Division. Only league administrators can create
+	// divisions.
+	CreateDivision(ctx context.Context, division *Division) error
+
+	// Renames or archives a Division. Only league administrators can update
+	// divisions.
+	UpdateDivision(ctx context.Context, id uint64, upd DivisionUpdate) (*Division, error)
 }
 
 type DivisionFilter struct {
-	ID   uint64 `json:"id"`
-	Name string `json:"name"`
+	ID       uint64 `json:"id"`
+	Name     string `json:"name"`
+	Archived *bool  `json:"archived"`
+	// Divisions of this organization instead of the request's
+	OrganizationID uint64 `json:"organization_id"`
 
 	// Restrict to subset of range.
 	Offset int `json:"offset"`
 	Limit  int `json:"limit"`
 }
+
+type DivisionUpdate struct {
+	Name     *string `json:"name"`
+	Archived *bool   `json:"archived"`
+}
@@ -20,6 +20,19 @@ type PlayerGame struct {
 	ReminderSent bool   `db:"reminder_sent"`
 }
 
+// A message posted to a game's discussion thread
+type GameMessage struct {
+	ID         uint64    `json:"id"`
+	GameID     uint64    `json:"game_id"`
+	PlayerID   uint64    `json:"player_id"`
+	PlayerName string    `json:"player_name"`
+	Body       string    `json:"body"`
+	CreatedOn  time.Time `json:"created_on"`
+}
+
+// Longest message that can be posted to a game
+const MaxMessageLength = 500
+
 type GameResponse struct {
 	Name    string
 	Players []string
@@ -54,17 +67,40 @@ type GameService interface {
 	// otherwise just use the current session player_id
 	UpdateStatus(ctx context.Context, game *Game, status string) error
 
+	// Sets the status of players with a default response for the game's team.
+	// Players who have already replied are left alone.
+	ApplyDefaultStatuses(ctx context.Context, game *Game) error
+
+	// ApplyDefaultStatuses for every game starting within window from now.
+	ApplyUpcomingDefaultStatuses(ctx context.Context, window time.Duration) error
+
+	// Returns the replies of the user in the context by game ID. Games they
+	// haven't replied to are left out.
+	PlayerStatuses(ctx context.Context) (map[uint64]string, error)
+
 	// Return the players bucketd by reply status for a game
 	ResponsesForGame(ctx context.Context, game *Game) (_ []*GameResponse, err error)
+
+	// Returns the game's discussion thread, oldest message first
+	Messages(ctx context.Context, game *Game) ([]*GameMessage, error)
+
+	// Posts a message from the user in the context to the game's thread. Only
+	// players on the game's team can post.
+	CreateMessage(ctx context.Context, msg *GameMessage) error
 }
 
 // GameFilter represents a filter used by FindGames().
 type GameFilter struct {
 	// Filtering fields.
-	ID       uint64 `json:"id"`
-	TeamID   uint64 `json:"team_id"`
-	PlayerID uint64 `json:"player_id"`
-	Time     int64  `json:"time"` // unix epoch seconds
+	ID         uint64 `json:"id"`
+	TeamID     uint64 `json:"team_id"`
+	PlayerID   uint64 `json:"player_id"`
+	DivisionID uint64 `json:"division_id"`
+	SeasonID   uint64 `json:"season_id"`
+	Time       int64  `json:"time"` // unix epoch seconds
+
+	// Leave out games of closed seasons
+	OpenSeasons bool `json:"open_seasons"`
 
 	// Restrict to subset of range.
 	Offset int `json:"offset"`
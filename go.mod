@@ -5,5 +5,6 @@ go 1.22.2
 require (
 	github.com/dchest/uniuri v1.2.0
 	github.com/mattn/go-sqlite3 v1.14.18
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	golang.org/x/crypto v0.15.0
 )
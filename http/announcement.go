@@ -0,0 +1,124 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+
+	teamvite "github.com/benprew/teamvite"
+)
+
+func (s *Server) teamAnnounce() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		team := teamvite.TeamFromContext(r.Context())
+		if !s.isManager(r.Context(), team) {
+			http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			return
+		}
+
+		a := teamvite.Announcement{TeamID: team.ID, Body: r.PostForm.Get("body")}
+		if err := s.AnnouncementService.CreateAnnouncement(r.Context(), &a); err != nil {
+			s.Error(w, r, err)
+			return
+		}
+
+		// sending can take a while for big teams, delivery status shows up on
+		// the team page as it completes. Delivery outlives the request, keep
+		// its organization for the links and sender.
+		ctx := teamvite.NewContextWithOrganization(context.Background(), teamvite.OrganizationFromContext(r.Context()))
+		go s.deliverAnnouncement(ctx, team, &a)
+
+		SetFlash(w, fmt.Sprintf("Sending announcement (%d messages)", a.Pending))
+		http.Redirect(w, r, UrlFor(team, "edit"), http.StatusFound)
+	})
+}
+
+var announcementMailTemplate = template.Must(template.New("announcement").Parse(`
+{{ .Announcement.PlayerName }} sent an announcement to {{ .Team.Name }}:<br>
+<blockquote>{{ .Announcement.Body }}</blockquote>
+<a href="{{ .URL }}">View the team</a>
+`))
+
+// deliverAnnouncement sends each pending delivery and records whether it
+// was sent.
+func (s *Server) deliverAnnouncement(ctx context.Context, team *teamvite.Team, a *teamvite.Announcement) {
+	deliveries, err := s.AnnouncementService.Deliveries(ctx, a.ID)
+	if err != nil {
+		log.Println("[ERROR] loading deliveries:", err)
+		return
+	}
+
+	var body bytes.Buffer
+	err = announcementMailTemplate.Execute(&body, map[string]interface{}{
+		"Team":         team,
+		"Announcement": a,
+		"URL":          fmt.Sprintf("https://%s%s", serverName(ctx), UrlFor(team, "show")),
+	})
+	if err != nil {
+		log.Println("[ERROR] building announcement email:", err)
+		return
+	}
+
+	for _, d := range deliveries {
+		if d.Status != teamvite.DeliveryPending {
+			continue
+		}
+		switch d.Channel {
+		case teamvite.ChannelEmail:
+			err = s.MailService.SendMail(ctx, &teamvite.Mail{
+				To:      []string{d.Email},
+				Subject: fmt.Sprintf("Announcement: %s", team.Name),
+				Body:    body.String(),
+			})
+		case teamvite.ChannelSMS:
+			err = s.SMSService.SendSMS(ctx, d.Phone, fmt.Sprintf("%s: %s", team.Name, a.Body))
+		default:
+			err = fmt.Errorf("unknown channel: %s", d.Channel)
+		}
+
+		d.Status = teamvite.DeliverySent
+		if err != nil {
+			log.Printf("[ERROR] delivering announcement %d to player %d by %s: %s\n", a.ID, d.PlayerID, d.Channel, err)
+			d.Status = teamvite.DeliveryFailed
+		}
+		if err := s.AnnouncementService.UpdateDelivery(ctx, d); err != nil {
+			log.Println("[ERROR] updating delivery:", err)
+		}
+	}
+}
+
+// ResumeAnnouncements sends deliveries left pending when the server last
+// stopped, from the league of each announcement's team.
+func (s *Server) ResumeAnnouncements(ctx context.Context) {
+	announcements, err := s.AnnouncementService.FindPendingAnnouncements(ctx)
+	if err != nil {
+		log.Println("[ERROR] loading pending announcements:", err)
+		return
+	}
+	for _, a := range announcements {
+		team, err := s.TeamService.FindTeamByID(ctx, a.TeamID)
+		if err != nil {
+			log.Printf("[ERROR] resuming announcement %d: %s\n", a.ID, err)
+			continue
+		}
+		orgID := team.OrganizationID
+		if orgID == 0 {
+			orgID = teamvite.DefaultOrganizationID
+		}
+		org, err := s.OrganizationService.FindOrganizationByID(ctx, orgID)
+		if err != nil {
+			log.Printf("[ERROR] resuming announcement %d: %s\n", a.ID, err)
+			continue
+		}
+		log.Printf("[INFO] resuming announcement %d (%d pending)\n", a.ID, a.Pending)
+		s.deliverAnnouncement(teamvite.NewContextWithOrganization(ctx, org), team, a)
+	}
+}
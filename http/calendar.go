@@ -0,0 +1,284 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	teamvite "github.com/benprew/teamvite"
+)
+
+// Calendar apps poll every few hours, anything more often than this is abuse
+var feedLimiter = newRateLimiter(20, time.Hour)
+
+// Limits clients guessing feed tokens. Only failed lookups count, calendar
+// apps like Google's fetch every subscriber's feed from a few shared IPs.
+var feedGuessLimiter = newRateLimiter(20, time.Hour)
+
+type CalendarParams struct {
+	Name       string
+	Games      []CalendarGame
+	CreateTime time.Time
+}
+
+type CalendarGame struct {
+	Url         string
+	Summary     string
+	Description string
+	Status      string // the player's reply, only in personal calendars
+	Start       *time.Time
+	End         *time.Time
+}
+
+// Deprecated: the team url is guessable, so it only works for public teams.
+// Members of other teams subscribe with their calendar feed.
+func (s *Server) teamCalendar() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		team := teamvite.TeamFromContext(r.Context())
+		if !feedLimiter.Allow(fmt.Sprintf("%s team %d", RequestIP(r), team.ID)) {
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		if team.Privacy != teamvite.TeamPublic {
+			s.Error(w, r, teamvite.Errorf(teamvite.ENOTFOUND, "This calendar is private, subscribe from your player page."))
+			return
+		}
+		s.renderCalendar(w, r, team)
+	})
+}
+
+// calendarFeed serves the calendar of a feed token, either one team's or all
+// of the player's teams.
+func (s *Server) calendarFeed() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := RequestIP(r).String()
+		if feedGuessLimiter.Blocked(ip) {
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		token := r.PathValue("token")
+		feed, err := s.CalendarFeedService.FindCalendarFeedByToken(r.Context(), token)
+		if teamvite.ErrorCode(err) == teamvite.ENOTFOUND {
+			feedGuessLimiter.Allow(ip)
+		}
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		if !feedLimiter.Allow("feed " + token) {
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		// the feed only shows what its player can see
+		ctx := teamvite.NewContextWithUser(r.Context(), &teamvite.Player{ID: feed.PlayerID})
+		if feed.TeamID == 0 {
+			player, err := s.PlayerService.FindPlayerByID(ctx, feed.PlayerID)
+			if err != nil {
+				s.Error(w, r, err)
+				return
+			}
+			s.renderPlayerCalendar(w, r.WithContext(ctx), player, feed.HideDeclined)
+			return
+		}
+		team, err := s.TeamService.FindTeamByID(ctx, feed.TeamID)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		if !team.RosterVisible() {
+			s.Error(w, r, teamvite.Errorf(teamvite.ENOTFOUND, "Calendar not found."))
+			return
+		}
+		s.renderCalendar(w, r, team)
+	})
+}
+
+func (s *Server) renderCalendar(w http.ResponseWriter, r *http.Request, team *teamvite.Team) {
+	games, _, err := s.GameService.FindGames(r.Context(), teamvite.GameFilter{TeamID: team.ID})
+	if err != nil {
+		s.Error(w, r, err)
+		return
+	}
+
+	cg := []CalendarGame{}
+	for _, g := range games {
+		cg = append(cg, calendarGame(r.Context(), g))
+	}
+	writeCalendar(w, CalendarParams{
+		Name:       team.Name,
+		Games:      cg,
+		CreateTime: time.Now(),
+	})
+}
+
+// playerCalendar merges the games of all the user's teams. Calendar apps
+// subscribe to the player's personal feed instead.
+func (s *Server) playerCalendar() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := teamvite.UserFromContext(r.Context())
+		player := teamvite.PlayerFromContext(r.Context())
+		if !feedLimiter.Allow(fmt.Sprintf("player %d", player.ID)) {
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		if user == nil || user.ID != player.ID {
+			s.Error(w, r, teamvite.Errorf(teamvite.ENOTFOUND, "Calendar not found."))
+			return
+		}
+		s.renderPlayerCalendar(w, r, player, r.URL.Query().Get("declined") == "hide")
+	})
+}
+
+func (s *Server) renderPlayerCalendar(w http.ResponseWriter, r *http.Request, player *teamvite.Player, hideDeclined bool) {
+	// look up the player's teams and replies as the player
+	ctx := teamvite.NewContextWithUser(teamvite.NewContextWithPlayer(r.Context(), "", player), player)
+	teams, err := s.PlayerService.Teams(ctx)
+	if err != nil {
+		s.Error(w, r, err)
+		return
+	}
+	teamNames := map[uint64]string{}
+	for _, pt := range teams {
+		teamNames[pt.Team.ID] = pt.Team.Name
+	}
+	statuses, err := s.GameService.PlayerStatuses(ctx)
+	if err != nil {
+		s.Error(w, r, err)
+		return
+	}
+	games, _, err := s.GameService.FindGames(ctx, teamvite.GameFilter{PlayerID: player.ID})
+	if err != nil {
+		s.Error(w, r, err)
+		return
+	}
+
+	cg := []CalendarGame{}
+	for _, g := range games {
+		status := statusName(statuses[g.ID])
+		if hideDeclined && status == "No" {
+			continue
+		}
+		c := calendarGame(r.Context(), g)
+		c.Summary = fmt.Sprintf("%s: %s", teamNames[g.TeamID], g.Description)
+		c.Status = status
+		cg = append(cg, c)
+	}
+	writeCalendar(w, CalendarParams{
+		Name:       fmt.Sprintf("%s's games", player.Name),
+		Games:      cg,
+		CreateTime: time.Now(),
+	})
+}
+
+func calendarGame(ctx context.Context, g *teamvite.Game) CalendarGame {
+	e := g.Time.Add(time.Minute * teamvite.GameLength)
+	return CalendarGame{
+		Url:         fmt.Sprintf("https://%s%s", serverName(ctx), UrlFor(g, "show")),
+		Summary:     g.Description,
+		Description: g.Description,
+		Start:       g.Time,
+		End:         &e,
+	}
+}
+
+// statusName is the reply shown for a players_games status.
+func statusName(status string) string {
+	status = strings.ToUpper(status)
+	switch {
+	case strings.HasPrefix(status, "Y"):
+		return "Yes"
+	case strings.HasPrefix(status, "N"):
+		return "No"
+	case strings.HasPrefix(status, "M"):
+		return "Maybe"
+	}
+	return "No reply"
+}
+
+func writeCalendar(w http.ResponseWriter, params CalendarParams) {
+	filename := "views/team/calendar.ics.tmpl"
+	// parse as text/template to avoid html escaping
+	t := template.Must(template.ParseFS(views, filename))
+	w.Header().Set("Content-Type", "text/calendar;charset=utf-8")
+	t.ExecuteTemplate(w, "calendar.ics.tmpl", params)
+}
+
+// Creates a calendar feed for one of the user's teams, or gives an existing
+// feed a new link.
+func (s *Server) playerCreateCalendarFeed() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := teamvite.UserFromContext(r.Context())
+		player := teamvite.PlayerFromContext(r.Context())
+		if user == nil || user.ID != player.ID {
+			s.Error(w, r, teamvite.Errorf(teamvite.EUNAUTHORIZED, "You can only change your own calendars."))
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, err)
+			return
+		}
+
+		// no team is a feed of all the player's teams
+		teamID, _ := strconv.ParseUint(r.PostForm.Get("team_id"), 10, 64)
+		feed, err := s.CalendarFeedService.CreateCalendarFeed(r.Context(), teamID)
+		msg := ""
+		if err == nil && feed.TeamID == 0 {
+			msg = "Created a new calendar link for all your teams, update it in your calendar app."
+		} else if err == nil {
+			msg = fmt.Sprintf("Created a new calendar link for %s, update it in your calendar app.", feed.TeamName)
+		}
+		s.redirectWithResult(w, r, err, UrlFor(player, "show"), msg)
+	})
+}
+
+func (s *Server) playerUpdateCalendarFeed() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := teamvite.UserFromContext(r.Context())
+		player := teamvite.PlayerFromContext(r.Context())
+		if user == nil || user.ID != player.ID {
+			s.Error(w, r, teamvite.Errorf(teamvite.EUNAUTHORIZED, "You can only change your own calendars."))
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, err)
+			return
+		}
+
+		feedID, _ := strconv.ParseUint(r.PostForm.Get("feed_id"), 10, 64)
+		_, err := s.CalendarFeedService.UpdateCalendarFeed(r.Context(), feedID, r.PostForm.Get("hide_declined") == "on")
+		s.redirectWithResult(w, r, err, UrlFor(player, "show"), "Updated your calendar.")
+	})
+}
+
+func (s *Server) playerRevokeCalendarFeed() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := teamvite.UserFromContext(r.Context())
+		player := teamvite.PlayerFromContext(r.Context())
+		if user == nil || user.ID != player.ID {
+			s.Error(w, r, teamvite.Errorf(teamvite.EUNAUTHORIZED, "You can only change your own calendars."))
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, err)
+			return
+		}
+
+		feedID, _ := strconv.ParseUint(r.PostForm.Get("feed_id"), 10, 64)
+		err := s.CalendarFeedService.DeleteCalendarFeed(r.Context(), feedID)
+		s.redirectWithResult(w, r, err, UrlFor(player, "show"), "Turned off the calendar link.")
+	})
+}
+
+// FeedUrl is the webcal url to subscribe to a calendar feed, on the host of
+// the request's league.
+func FeedUrl(ctx context.Context, f *teamvite.CalendarFeed) htmltemplate.URL {
+	return htmltemplate.URL(fmt.Sprintf(
+		"webcal://%s/feed/%s/calendar.ics",
+		serverName(ctx), f.Token))
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 
@@ -11,12 +12,31 @@ import (
 type divisionListParams struct {
 	Divisions []*teamvite.Division
 	Q         string // query
+	IsAdmin   bool
+}
+
+type divisionShowParams struct {
+	Division *teamvite.Division `json:"division"`
+	Teams    []*teamvite.Team   `json:"teams"`
+	Games    []*divisionGame    `json:"games"`
+	IsAdmin  bool               `json:"-"`
+}
+
+type divisionGame struct {
+	*teamvite.Game
+	TeamName string `json:"team_name"`
 }
 
 func (s *Server) DivisionList() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		nameQuery := r.URL.Query().Get("name")
-		divisions, _, err := s.DivisionService.FindDivisions(r.Context(), teamvite.DivisionFilter{Name: nameQuery})
+		filter := teamvite.DivisionFilter{Name: nameQuery}
+		// archived divisions are only listed when asked for
+		if r.URL.Query().Get("archived") == "" {
+			archived := false
+			filter.Archived = &archived
+		}
+		divisions, _, err := s.DivisionService.FindDivisions(r.Context(), filter)
 		if err != nil {
 			s.Error(w, r, err)
 			return
@@ -30,6 +50,7 @@ func (s *Server) DivisionList() http.Handler {
 			templateParams := divisionListParams{
 				Q:         nameQuery,
 				Divisions: divisions,
+				IsAdmin:   isAdmin(r),
 			}
 			err = s.RenderTemplate(w, r, teamvite.TemplateFromContext(r.Context()), templateParams)
 			if err != nil {
@@ -40,3 +61,135 @@ func (s *Server) DivisionList() http.Handler {
 		}
 	})
 }
+
+// Shows the division's teams and the schedule of all their games.
+func (s *Server) divisionShow() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		division := teamvite.DivisionFromContext(ctx)
+		teams, _, err := s.TeamService.FindTeams(ctx, teamvite.TeamFilter{DivisionID: division.ID})
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		games, _, err := s.GameService.FindGames(ctx, teamvite.GameFilter{DivisionID: division.ID})
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+
+		// games of hidden teams aren't shown
+		teamNames := map[uint64]string{}
+		for _, t := range teams {
+			teamNames[t.ID] = t.Name
+		}
+		params := divisionShowParams{
+			Division: division,
+			Teams:    teams,
+			Games:    []*divisionGame{},
+			IsAdmin:  isAdmin(r),
+		}
+		for _, g := range games {
+			if name, ok := teamNames[g.TeamID]; ok {
+				params.Games = append(params.Games, &divisionGame{Game: g, TeamName: name})
+			}
+		}
+
+		switch r.Header.Get("Content-type") {
+		case JSON:
+			w.Header().Set("Content-Type", JSON)
+			json.NewEncoder(w).Encode(params)
+		default:
+			s.RenderTemplate(w, r, teamvite.TemplateFromContext(ctx), params)
+		}
+	})
+}
+
+// curl -i -X POST --silent \
+// http://teamvitedev.com:8080/division \
+// -H 'Content-Type: application/json' \
+// --data '{"name":"Coed C1"}'
+func (s *Server) divisionCreate() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var d teamvite.Division
+		switch r.Header.Get("Content-type") {
+		case JSON:
+			if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+				s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid JSON body."))
+				return
+			}
+			if err := s.DivisionService.CreateDivision(r.Context(), &d); err != nil {
+				s.Error(w, r, err)
+				return
+			}
+			w.Header().Set("Content-Type", JSON)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(d)
+		default:
+			if err := r.ParseForm(); err != nil {
+				s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+				return
+			}
+			d.Name = r.PostForm.Get("name")
+			err := s.DivisionService.CreateDivision(r.Context(), &d)
+			url := "/division"
+			if err == nil {
+				url = UrlFor(&d, "show")
+			}
+			s.redirectWithResult(w, r, err, url, fmt.Sprintf("Created %s", d.Name))
+		}
+	})
+}
+
+func (s *Server) divisionUpdate() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		division := teamvite.DivisionFromContext(r.Context())
+
+		var upd teamvite.DivisionUpdate
+		switch r.Header.Get("Content-type") {
+		case JSON:
+			if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+				s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid JSON body."))
+				return
+			}
+			d, err := s.DivisionService.UpdateDivision(r.Context(), division.ID, upd)
+			if err != nil {
+				s.Error(w, r, err)
+				return
+			}
+			w.Header().Set("Content-Type", JSON)
+			json.NewEncoder(w).Encode(d)
+		default:
+			if err := r.ParseForm(); err != nil {
+				s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+				return
+			}
+			name := r.PostForm.Get("name")
+			archived := r.PostForm.Get("archived") == "on"
+			upd.Name, upd.Archived = &name, &archived
+			_, err := s.DivisionService.UpdateDivision(r.Context(), division.ID, upd)
+			s.redirectWithResult(w, r, err, UrlFor(division, "show"), "Division updated")
+		}
+	})
+}
+
+// Divisions are archived rather than deleted, as old teams and games still
+// belong to them.
+func (s *Server) divisionArchive() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		division := teamvite.DivisionFromContext(r.Context())
+		archived := true
+		d, err := s.DivisionService.UpdateDivision(r.Context(), division.ID, teamvite.DivisionUpdate{Archived: &archived})
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", JSON)
+		json.NewEncoder(w).Encode(d)
+	})
+}
+
+func isAdmin(r *http.Request) bool {
+	user := teamvite.UserFromContext(r.Context())
+	return user != nil && user.IsAdmin
+}
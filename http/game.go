@@ -1,8 +1,11 @@
 package http
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"html/template"
 	"log"
 	"net/http"
 	"strings"
@@ -19,9 +22,16 @@ type GameShowParams struct {
 	User       teamvite.Player
 	Game       teamvite.Game
 	Responses  []*teamvite.GameResponse
+	Messages   []*teamvite.GameMessage
 	ShowStatus bool
 }
 
+type gameJSON struct {
+	Game      *teamvite.Game           `json:"game"`
+	Responses []*teamvite.GameResponse `json:"responses"`
+	Messages  []*teamvite.GameMessage  `json:"messages"`
+}
+
 func (s *Server) buildGameContext(r *http.Request) (GameCtx, error) {
 	routeInfo, err := buildRouteInfo(r.URL.EscapedPath())
 	if err != nil {
@@ -96,15 +106,40 @@ func (s *Server) gameShow() http.Handler {
 				msg = "Sh*t or get off the pot!"
 
 			}
-			if err = s.GameService.UpdateStatus(r.Context(), g, status[0:1]); err != nil {
+			// links in reminders carry a token that can only reply to this game
+			statusCtx := r.Context()
+			if token := r.URL.Query().Get(ACTION_TOKEN_KEY); token != "" {
+				t, err := s.ActionTokenService.FindActionToken(r.Context(), token, g.ID, teamvite.ActionRSVP)
+				if teamvite.ErrorCode(err) == teamvite.ENOTFOUND {
+					SetFlash(w, teamvite.ErrorMessage(err))
+					http.Redirect(w, r, "/user/login", http.StatusFound)
+					return
+				} else if err != nil {
+					s.Error(w, r, err)
+					return
+				}
+				statusCtx = teamvite.NewContextWithUser(statusCtx, &teamvite.Player{ID: t.PlayerID})
+			} else if userID == 0 {
+				SetFlash(w, "Log in to reply to the game.")
+				http.Redirect(w, r, "/user/login", http.StatusFound)
+				return
+			}
+			if err = s.GameService.UpdateStatus(statusCtx, g, status[0:1]); err != nil {
 				s.Error(w, r, err)
 				return
 			}
 			// this redirects here because I want to accept GET requests from email links
 			// so instead of having a POST route and a GET route there's a singe GET route
 			// that strips the status param off after updating the game status.
+			// without a session the game of a hidden team isn't found again
+			// once the token is stripped
+			next := UrlFor(g, "show")
+			if team := teamvite.TeamFromContext(r.Context()); userID == 0 && team.Privacy == teamvite.TeamHidden {
+				next = "/user/login"
+			}
 			SetFlash(w, msg)
-			http.Redirect(w, r, UrlFor(g, "show"), http.StatusFound)
+			http.Redirect(w, r, next, http.StatusFound)
+			return
 		}
 		_, n, err := s.GameService.FindGames(
 			r.Context(),
@@ -116,17 +151,105 @@ func (s *Server) gameShow() http.Handler {
 			return
 		}
 
-		responses, err := s.GameService.ResponsesForGame(r.Context(), g)
-		if err != nil {
+		// non-members of private teams only see the schedule
+		var responses []*teamvite.GameResponse
+		var messages []*teamvite.GameMessage
+		if team := teamvite.TeamFromContext(r.Context()); team.RosterVisible() {
+			responses, err = s.GameService.ResponsesForGame(r.Context(), g)
+			if err != nil {
+				s.Error(w, r, err)
+				return
+			}
+
+			messages, err = s.GameService.Messages(r.Context(), g)
+			if err != nil {
+				s.Error(w, r, err)
+				return
+			}
+		}
+
+		switch r.Header.Get("Content-type") {
+		case JSON:
+			w.Header().Set("Content-Type", JSON)
+			json.NewEncoder(w).Encode(gameJSON{Game: g, Responses: responses, Messages: messages})
+		default:
+			templateParams := GameShowParams{
+				Game:       *g,
+				Responses:  responses,
+				Messages:   messages,
+				ShowStatus: userGameStatus,
+			}
+			s.RenderTemplate(w, r, ctx.Template, templateParams)
+		}
+	})
+}
+
+func (s *Server) gameMessageCreate() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g := teamvite.GameFromContext(r.Context())
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			return
+		}
+
+		msg := teamvite.GameMessage{GameID: g.ID, Body: r.PostForm.Get("body")}
+		if err := s.GameService.CreateMessage(r.Context(), &msg); err != nil {
 			s.Error(w, r, err)
 			return
 		}
 
-		templateParams := GameShowParams{
-			Game:       *g,
-			Responses:  responses,
-			ShowStatus: userGameStatus,
+		if r.PostForm.Get("notify") != "" {
+			// like announcements, mail goes out after the redirect. Keep the
+			// organization for the links and sender, and the poster so the
+			// roster is visible.
+			ctx := teamvite.NewContextWithOrganization(context.Background(), teamvite.OrganizationFromContext(r.Context()))
+			ctx = teamvite.NewContextWithUser(ctx, teamvite.UserFromContext(r.Context()))
+			go s.notifyAttendees(ctx, g, &msg)
 		}
-		s.RenderTemplate(w, r, ctx.Template, templateParams)
+		http.Redirect(w, r, UrlFor(g, "show"), http.StatusFound)
+	})
+}
+
+var messageMailTemplate = template.Must(template.New("message").Parse(`
+{{ .Message.PlayerName }} posted a message about {{ .Game.Description }}:<br>
+<blockquote>{{ .Message.Body }}</blockquote>
+<a href="{{ .URL }}">View the game</a>
+`))
+
+// notifyAttendees emails players coming (or maybe coming) to the game about a
+// new message. Failures are logged, the message has already been posted.
+func (s *Server) notifyAttendees(ctx context.Context, g *teamvite.Game, msg *teamvite.GameMessage) {
+	players, _, err := s.PlayerService.FindPlayers(ctx, teamvite.PlayerFilter{
+		GameID:       &g.ID,
+		GameStatuses: []string{"Y", "M"},
+	})
+	if err != nil {
+		log.Println("[ERROR] finding attendees:", err)
+		return
+	}
+
+	var body bytes.Buffer
+	err = messageMailTemplate.Execute(&body, map[string]interface{}{
+		"Game":    g,
+		"Message": msg,
+		"URL":     fmt.Sprintf("https://%s%s", serverName(ctx), UrlFor(g, "show")),
 	})
+	if err != nil {
+		log.Println("[ERROR] building message email:", err)
+		return
+	}
+
+	for _, p := range players {
+		if p.ID == msg.PlayerID || p.Email == "" {
+			continue
+		}
+		err := s.MailService.SendMail(ctx, &teamvite.Mail{
+			To:      []string{p.Email},
+			Subject: fmt.Sprintf("New message: %s", g.Description),
+			Body:    body.String(),
+		})
+		if err != nil {
+			log.Printf("[ERROR] emailing message to player %d: %s\n", p.ID, err)
+		}
+	}
 }
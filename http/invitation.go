@@ -0,0 +1,149 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	teamvite "github.com/benprew/teamvite"
+)
+
+// teamInvite sends an invitation to join the team. The player is only added
+// to the roster once they accept.
+func (s *Server) teamInvite() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		team := teamvite.TeamFromContext(r.Context())
+		if !s.isManager(r.Context(), team) {
+			http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			return
+		}
+
+		inv := teamvite.Invitation{
+			TeamID: team.ID,
+			Email:  r.PostForm.Get("email"),
+			Name:   r.PostForm.Get("name"),
+		}
+		err := s.InvitationService.CreateInvitation(r.Context(), &inv)
+		if err == nil {
+			err = s.sendInvitation(r.Context(), &inv)
+		}
+		s.redirectWithResult(w, r, err, UrlFor(team, "edit"), fmt.Sprintf("Invitation sent to %s", inv.Email))
+	})
+}
+
+func (s *Server) teamResendInvitation() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		team := teamvite.TeamFromContext(r.Context())
+		if !s.isManager(r.Context(), team) {
+			http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			return
+		}
+		id, _ := strconv.ParseUint(r.PostForm.Get("invitation_id"), 10, 64)
+
+		inv, err := s.InvitationService.RenewInvitation(r.Context(), team.ID, id)
+		if err == nil {
+			err = s.sendInvitation(r.Context(), inv)
+		}
+		msg := ""
+		if inv != nil {
+			msg = fmt.Sprintf("Invitation resent to %s", inv.Email)
+		}
+		s.redirectWithResult(w, r, err, UrlFor(team, "edit"), msg)
+	})
+}
+
+func (s *Server) teamRevokeInvitation() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		team := teamvite.TeamFromContext(r.Context())
+		if !s.isManager(r.Context(), team) {
+			http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			return
+		}
+		id, _ := strconv.ParseUint(r.PostForm.Get("invitation_id"), 10, 64)
+
+		err := s.InvitationService.RevokeInvitation(r.Context(), team.ID, id)
+		s.redirectWithResult(w, r, err, UrlFor(team, "edit"), "Invitation revoked")
+	})
+}
+
+// Accepting and declining are done as GETs so the links in the invitation
+// email work, like game status links in reminders.
+func (s *Server) invitationAccept() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inv, err := s.InvitationService.AcceptInvitation(r.Context(), r.URL.Query().Get("token"))
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		SetFlash(w, fmt.Sprintf("Welcome to %s!", inv.TeamName))
+		http.Redirect(w, r, fmt.Sprintf("/team/%d/show", inv.TeamID), http.StatusFound)
+	})
+}
+
+func (s *Server) invitationDecline() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inv, err := s.InvitationService.DeclineInvitation(r.Context(), r.URL.Query().Get("token"))
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		SetFlash(w, fmt.Sprintf("You declined the invitation to %s", inv.TeamName))
+		http.Redirect(w, r, "/", http.StatusFound)
+	})
+}
+
+var invitationMailTemplate = template.Must(template.New("invitation").Parse(`
+{{ if .Invitation.Name }}Dear {{ .Invitation.Name }},<br>{{ end }}
+{{ .Invitation.InvitedByName }} has invited you to join {{ .Invitation.TeamName }} on {{ .League }}.
+<ul>
+  <li><a href="{{ .AcceptURL }}">Join the team</a></li>
+  <li><a href="{{ .DeclineURL }}">No thanks</a></li>
+</ul>
+This invitation expires on {{ .Invitation.ExpiresOn.Format "Mon Jan 2" }}.<br>
+
+Thank you for using {{ .League }}!
+`))
+
+func (s *Server) sendInvitation(ctx context.Context, inv *teamvite.Invitation) error {
+	tokenURL := func(action string) string {
+		return fmt.Sprintf("https://%s/invitation/%s?token=%s",
+			serverName(ctx), action, url.QueryEscape(inv.Token))
+	}
+
+	league := teamvite.OrganizationFromContext(ctx).Name
+	var body bytes.Buffer
+	err := invitationMailTemplate.Execute(&body, map[string]interface{}{
+		"League":     league,
+		"Invitation": inv,
+		"AcceptURL":  tokenURL("accept"),
+		"DeclineURL": tokenURL("decline"),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.MailService.SendMail(ctx, &teamvite.Mail{
+		To:      []string{inv.Email},
+		Subject: fmt.Sprintf("Join %s on %s", inv.TeamName, league),
+		Body:    body.String(),
+	})
+}
@@ -0,0 +1,162 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	teamvite "github.com/benprew/teamvite"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+type teamJoinParams struct {
+	Team    *teamvite.Team
+	JoinURL string
+	Pending bool
+}
+
+// teamJoin is the page the shareable join link goes to.
+func (s *Server) teamJoin() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		team := teamvite.TeamFromContext(ctx)
+		userID := teamvite.UserIDFromContext(ctx)
+		if userID == 0 {
+			SetFlash(w, fmt.Sprintf("Log in to ask to join %s", team.Name))
+			http.Redirect(w, r, "/user/login", http.StatusFound)
+			return
+		}
+
+		if team.IsMember {
+			http.Redirect(w, r, UrlFor(team, "show"), http.StatusFound)
+			return
+		}
+
+		pending := teamvite.JoinRequestPending
+		reqs, err := s.JoinRequestService.FindJoinRequests(ctx, teamvite.JoinRequestFilter{
+			TeamID: team.ID, PlayerID: userID, Status: &pending})
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+
+		s.RenderTemplate(w, r, teamvite.TemplateFromContext(ctx), teamJoinParams{
+			Team:    team,
+			JoinURL: JoinURL(team),
+			Pending: len(reqs) > 0,
+		})
+	})
+}
+
+func (s *Server) teamJoinRequest() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		team := teamvite.TeamFromContext(ctx)
+		if teamvite.UserIDFromContext(ctx) == 0 {
+			http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			return
+		}
+
+		req := teamvite.JoinRequest{TeamID: team.ID, Message: r.PostForm.Get("message")}
+		err := s.JoinRequestService.CreateJoinRequest(ctx, &req)
+		if err == nil {
+			if err := s.notifyManagers(ctx, team, &req); err != nil {
+				log.Printf("[ERROR] notifying managers of join request %d: %s", req.ID, err)
+			}
+		}
+		s.redirectWithResult(w, r, err, UrlFor(team, "join"), "Your request was sent to the team managers")
+	})
+}
+
+// teamRespondToJoinRequest approves or rejects a pending join request.
+func (s *Server) teamRespondToJoinRequest(approve bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		team := teamvite.TeamFromContext(ctx)
+		if !s.isManager(ctx, team) {
+			http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			return
+		}
+		id, _ := strconv.ParseUint(r.PostForm.Get("join_request_id"), 10, 64)
+
+		if !approve {
+			req, err := s.JoinRequestService.UpdateJoinRequest(ctx, team.ID, id, teamvite.JoinRequestRejected)
+			msg := ""
+			if req != nil {
+				msg = fmt.Sprintf("Rejected %s", req.PlayerName)
+			}
+			s.redirectWithResult(w, r, err, UrlFor(team, "edit"), msg)
+			return
+		}
+
+		req, err := s.JoinRequestService.UpdateJoinRequest(ctx, team.ID, id, teamvite.JoinRequestApproved)
+		msg := ""
+		if req != nil {
+			msg = fmt.Sprintf("Added %s to the team", req.PlayerName)
+		}
+		s.redirectWithResult(w, r, err, UrlFor(team, "edit"), msg)
+	})
+}
+
+// teamJoinQRCode returns a QR code of the team's join link for managers to
+// print and post at the field.
+func (s *Server) teamJoinQRCode() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		team := teamvite.TeamFromContext(r.Context())
+		png, err := qrcode.Encode(JoinURL(team), qrcode.Medium, 256)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(png)
+	})
+}
+
+func JoinURL(t *teamvite.Team) string {
+	return fmt.Sprintf("https://%s%s", teamvite.CONFIG.Servername, UrlFor(t, "join"))
+}
+
+var joinRequestMailTemplate = template.Must(template.New("join_request").Parse(`
+{{ .Request.PlayerName }} ({{ .Request.PlayerEmail }}) has asked to join {{ .Request.TeamName }}.<br>
+{{ with .Request.Message }}<blockquote>{{ . }}</blockquote>{{ end }}
+<a href="{{ .EditURL }}">Approve or reject the request</a><br>
+
+Thank you for using {{ .League }}!
+`))
+
+func (s *Server) notifyManagers(ctx context.Context, team *teamvite.Team, req *teamvite.JoinRequest) error {
+	if len(req.ManagerEmails) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	err := joinRequestMailTemplate.Execute(&body, map[string]interface{}{
+		"League":  teamvite.OrganizationFromContext(ctx).Name,
+		"Request": req,
+		"EditURL": fmt.Sprintf("https://%s%s", serverName(ctx), UrlFor(team, "edit")),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.MailService.SendMail(ctx, &teamvite.Mail{
+		To:      req.ManagerEmails,
+		Subject: fmt.Sprintf("%s wants to join %s", req.PlayerName, team.Name),
+		Body:    body.String(),
+	})
+}
@@ -0,0 +1,36 @@
+package http
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	teamvite "github.com/benprew/teamvite"
+)
+
+// organizationMiddleware puts the organization (league) served from the
+// request's host in the context. Hosts that aren't an organization's get the
+// default organization.
+func (s *Server) organizationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		org, err := s.OrganizationService.FindOrganizationByHost(r.Context(), host)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(teamvite.NewContextWithOrganization(r.Context(), org)))
+	})
+}
+
+// serverName is the host links in mail point to, the organization's so
+// players stay on their league's site.
+func serverName(ctx context.Context) string {
+	if org := teamvite.OrganizationFromContext(ctx); org != nil && org.Host != "" {
+		return org.Host
+	}
+	return teamvite.CONFIG.Servername
+}
@@ -0,0 +1,274 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	teamvite "github.com/benprew/teamvite"
+	"github.com/benprew/teamvite/webauthn"
+)
+
+// each login attempt is a signature check, so limit them per IP
+var passkeyLimiter = newRateLimiter(30, time.Hour)
+
+type passkeyListParams struct {
+	Passkeys []*teamvite.Passkey
+}
+
+type passkeyRegistration struct {
+	Name      string `json:"name"`
+	Challenge string `json:"challenge"`
+	webauthn.Registration
+}
+
+type passkeyLogin struct {
+	Challenge string `json:"challenge"`
+	webauthn.Assertion
+}
+
+// the descriptor of a credential in WebAuthn options
+type passkeyDescriptor struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// relyingParty is the request's host, so each league's domain has its own
+// passkeys. Browsers only allow WebAuthn over http on localhost.
+func relyingParty(r *http.Request) webauthn.RelyingParty {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	scheme := "https"
+	if host == "localhost" {
+		scheme = "http"
+	}
+	return webauthn.RelyingParty{ID: host, Origin: fmt.Sprintf("%s://%s", scheme, r.Host)}
+}
+
+// Lists the player's passkeys and lets them add one for this device.
+func (s *Server) userPasskeys() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := s.GetUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/user/login", http.StatusFound)
+			return
+		}
+		passkeys, err := s.PasskeyService.FindPasskeys(r.Context(), user.ID)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		s.RenderTemplate(w, r, "views/user/passkeys.tmpl", passkeyListParams{Passkeys: passkeys})
+	})
+}
+
+// Options for navigator.credentials.create, with a new challenge.
+func (s *Server) userPasskeyRegisterOptions() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := s.GetUser(r)
+		if user == nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EUNAUTHORIZED, "You must be logged in to add a passkey."))
+			return
+		}
+		passkeys, err := s.PasskeyService.FindPasskeys(r.Context(), user.ID)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		challenge := webauthn.NewChallenge()
+		if err := s.PasskeyService.CreatePasskeyChallenge(r.Context(), challenge, user.ID); err != nil {
+			s.Error(w, r, err)
+			return
+		}
+
+		// a device can't register twice
+		exclude := []passkeyDescriptor{}
+		for _, p := range passkeys {
+			exclude = append(exclude, passkeyDescriptor{Type: "public-key", ID: webauthn.Encode(p.CredentialID)})
+		}
+		params := []map[string]interface{}{}
+		for _, alg := range webauthn.Algorithms {
+			params = append(params, map[string]interface{}{"type": "public-key", "alg": alg})
+		}
+		rp := relyingParty(r)
+		w.Header().Set("Content-Type", JSON)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"challenge": challenge,
+			"rp":        map[string]string{"id": rp.ID, "name": teamvite.OrganizationFromContext(r.Context()).Name},
+			"user": map[string]string{
+				"id":          webauthn.Encode([]byte(strconv.FormatUint(user.ID, 10))),
+				"name":        user.Email,
+				"displayName": user.Name,
+			},
+			"pubKeyCredParams":   params,
+			"excludeCredentials": exclude,
+			// passkeys are discoverable, so logging in doesn't need an email
+			"authenticatorSelection": map[string]string{"residentKey": "required", "userVerification": "required"},
+			"attestation":            "none",
+			"timeout":                teamvite.PasskeyChallengeLength.Milliseconds(),
+		})
+	})
+}
+
+// Registers the passkey the browser created.
+func (s *Server) userPasskeyRegister() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := s.GetUser(r)
+		if user == nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EUNAUTHORIZED, "You must be logged in to add a passkey."))
+			return
+		}
+		var reg passkeyRegistration
+		if err := json.NewDecoder(r.Body).Decode(&reg); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid JSON body."))
+			return
+		}
+
+		playerID, err := s.PasskeyService.UsePasskeyChallenge(r.Context(), reg.Challenge)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		if playerID != user.ID {
+			s.Error(w, r, teamvite.Errorf(teamvite.EUNAUTHORIZED, "That passkey was started by another account."))
+			return
+		}
+		cred, err := relyingParty(r).VerifyRegistration(reg.Registration, reg.Challenge)
+		if err != nil {
+			log.Printf("[WARN] passkey registration [player_id=%d]: %s\n", user.ID, err)
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Your passkey couldn't be verified, please try again."))
+			return
+		}
+
+		passkey := teamvite.Passkey{
+			PlayerID:     user.ID,
+			Name:         reg.Name,
+			CredentialID: cred.ID,
+			PublicKey:    cred.PublicKey,
+			SignCount:    cred.SignCount,
+		}
+		if err := s.PasskeyService.CreatePasskey(r.Context(), &passkey); err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		log.Printf("registered passkey [player_id=%d]\n", user.ID)
+		w.Header().Set("Content-Type", JSON)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(passkey)
+	})
+}
+
+func (s *Server) userDeletePasskey() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := s.GetUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/user/login", http.StatusFound)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			return
+		}
+		id, _ := strconv.ParseUint(r.PostForm.Get("id"), 10, 64)
+		err := s.PasskeyService.DeletePasskey(r.Context(), user.ID, id)
+		if teamvite.ErrorCode(err) == teamvite.ENOTFOUND {
+			SetFlash(w, teamvite.ErrorMessage(err))
+			http.Redirect(w, r, "/user/passkeys", http.StatusFound)
+			return
+		}
+		s.redirectWithResult(w, r, err, "/user/passkeys", "Removed the passkey.")
+	})
+}
+
+// Options for navigator.credentials.get. The challenge isn't tied to a
+// player, the passkey says who is logging in.
+func (s *Server) userPasskeyLoginOptions() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !passkeyLimiter.Allow(RequestIP(r).String()) {
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		challenge := webauthn.NewChallenge()
+		if err := s.PasskeyService.CreatePasskeyChallenge(r.Context(), challenge, 0); err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		w.Header().Set("Content-Type", JSON)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"challenge":        challenge,
+			"rpId":             relyingParty(r).ID,
+			"userVerification": "required",
+			"timeout":          teamvite.PasskeyChallengeLength.Milliseconds(),
+		})
+	})
+}
+
+// Logs in with a passkey. The device checked the player's fingerprint, face or
+// PIN, so the session counts as two-factor.
+func (s *Server) userPasskeyLogin() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var login passkeyLogin
+		if err := json.NewDecoder(r.Body).Decode(&login); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid JSON body."))
+			return
+		}
+		if playerID, err := s.PasskeyService.UsePasskeyChallenge(r.Context(), login.Challenge); err != nil {
+			s.Error(w, r, err)
+			return
+		} else if playerID != 0 {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "That challenge isn't for logging in."))
+			return
+		}
+
+		credentialID, err := webauthn.Decode(login.CredentialID)
+		if err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid credential."))
+			return
+		}
+		passkey, err := s.PasskeyService.FindPasskeyByCredentialID(r.Context(), credentialID)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		// the user handle, when sent, is the player ID the passkey was made for
+		if login.UserHandle != "" {
+			if handle, err := webauthn.Decode(login.UserHandle); err != nil || string(handle) != strconv.FormatUint(passkey.PlayerID, 10) {
+				s.Error(w, r, teamvite.Errorf(teamvite.EUNAUTHORIZED, "Your passkey couldn't be verified."))
+				return
+			}
+		}
+		cred := webauthn.Credential{ID: passkey.CredentialID, PublicKey: passkey.PublicKey, SignCount: passkey.SignCount}
+		signCount, err := relyingParty(r).VerifyAssertion(login.Assertion, login.Challenge, &cred)
+		if err != nil {
+			log.Printf("[WARN] passkey login [player_id=%d]: %s\n", passkey.PlayerID, err)
+			s.Error(w, r, teamvite.Errorf(teamvite.EUNAUTHORIZED, "Your passkey couldn't be verified."))
+			return
+		}
+		if err := s.PasskeyService.UsePasskey(r.Context(), passkey.ID, signCount); err != nil {
+			s.Error(w, r, err)
+			return
+		}
+
+		session, err := s.SessionService.New(passkey.PlayerID, RequestIP(r), time.Hour*24*30)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		if err := s.SessionService.MarkTwoFactor(session.ID); err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		s.setSession(w, session)
+		log.Printf("created session from passkey [player_id=%d]\n", passkey.PlayerID)
+		w.Header().Set("Content-Type", JSON)
+		json.NewEncoder(w).Encode(map[string]string{
+			"redirect": UrlFor(&teamvite.Player{ID: passkey.PlayerID}, "show"),
+		})
+	})
+}
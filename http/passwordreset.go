@@ -0,0 +1,156 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	teamvite "github.com/benprew/teamvite"
+)
+
+// each request sends mail, so limit them per IP and per email
+var resetLimiter = newRateLimiter(5, time.Hour)
+
+type resetPasswordParams struct {
+	Token string
+	Email string
+}
+
+func (s *Server) userForgotPassword() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.RenderTemplate(w, r, "views/user/forgot_password.tmpl", nil)
+	})
+}
+
+// Emails a reset link. The response is the same whether or not there is an
+// account, so it can't be used to find out who has one.
+func (s *Server) userForgotPasswordPost() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			return
+		}
+		email := strings.ToLower(strings.TrimSpace(r.PostForm.Get("email")))
+		if !resetLimiter.Allow("ip "+RequestIP(r).String()) || !resetLimiter.Allow("email "+email) {
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		reset, err := s.PasswordResetService.CreatePasswordReset(r.Context(), email)
+		switch teamvite.ErrorCode(err) {
+		case "":
+			if err := s.sendPasswordReset(r.Context(), reset); err != nil {
+				s.Error(w, r, err)
+				return
+			}
+		case teamvite.ENOTFOUND:
+			log.Printf("[INFO] password reset for unknown email: %s", email)
+		default:
+			s.Error(w, r, err)
+			return
+		}
+		SetFlash(w, fmt.Sprintf("If there is an account for %s, we emailed it a link to reset the password.", email))
+		http.Redirect(w, r, "/user/login", http.StatusFound)
+	})
+}
+
+func (s *Server) userResetPassword() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reset, err := s.PasswordResetService.FindPasswordReset(r.Context(), r.URL.Query().Get("token"))
+		if teamvite.ErrorCode(err) == teamvite.ENOTFOUND {
+			SetFlash(w, teamvite.ErrorMessage(err))
+			http.Redirect(w, r, "/user/forgot_password", http.StatusFound)
+			return
+		} else if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		s.RenderTemplate(w, r, "views/user/reset_password.tmpl", resetPasswordParams{Token: reset.Token, Email: reset.Email})
+	})
+}
+
+// Sets the new password, logs the player out everywhere else and logs them in
+// here.
+func (s *Server) userResetPasswordPost() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			return
+		}
+		token := r.PostForm.Get("token")
+		password := r.PostForm.Get("password")
+		formURL := "/user/reset_password?token=" + url.QueryEscape(token)
+		if len(password) < teamvite.MinPasswordLength {
+			SetFlash(w, fmt.Sprintf("Passwords must be at least %d characters.", teamvite.MinPasswordLength))
+			http.Redirect(w, r, formURL, http.StatusFound)
+			return
+		}
+		if password != r.PostForm.Get("confirm") {
+			SetFlash(w, "The passwords don't match.")
+			http.Redirect(w, r, formURL, http.StatusFound)
+			return
+		}
+
+		reset, err := s.PasswordResetService.UsePasswordReset(r.Context(), token)
+		if teamvite.ErrorCode(err) == teamvite.ENOTFOUND {
+			SetFlash(w, teamvite.ErrorMessage(err))
+			http.Redirect(w, r, "/user/forgot_password", http.StatusFound)
+			return
+		} else if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		player, err := s.PlayerService.FindPlayerByID(r.Context(), reset.PlayerID)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		ctx := teamvite.NewContextWithPlayer(r.Context(), "", player)
+		if err := s.PlayerService.ResetPassword(ctx, password); err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		if err := s.SessionService.RevokeAll(player.ID); err != nil {
+			s.Error(w, r, err)
+			return
+		}
+
+		log.Printf("reset password [player_id=%d]\n", player.ID)
+		s.logIn(w, r, player, UrlFor(player, "show"), "Your password has been changed.")
+	})
+}
+
+var passwordResetMailTemplate = template.Must(template.New("password_reset").Parse(`
+Someone asked to reset the password of your account. Follow this link to choose a new one:
+<a href="{{ .ResetURL }}">{{ .ResetURL }}</a><br>
+The link works once and expires at {{ .Reset.ExpiresOn.Local.Format "3:04PM" }}. If you didn't ask to reset your
+password you can ignore this email.<br>
+
+Thank you for using {{ .League }}!
+`))
+
+func (s *Server) sendPasswordReset(ctx context.Context, reset *teamvite.PasswordReset) error {
+	league := teamvite.OrganizationFromContext(ctx).Name
+	var body bytes.Buffer
+	err := passwordResetMailTemplate.Execute(&body, map[string]interface{}{
+		"League": league,
+		"Reset":  reset,
+		"ResetURL": fmt.Sprintf("https://%s/user/reset_password?token=%s",
+			serverName(ctx), url.QueryEscape(reset.Token)),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.MailService.SendMail(ctx, &teamvite.Mail{
+		To:      []string{reset.Email},
+		Subject: fmt.Sprintf("Reset your %s password", league),
+		Body:    body.String(),
+	})
+}
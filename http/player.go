@@ -2,8 +2,10 @@ package http
 
 import (
 	"fmt"
+	htmltemplate "html/template"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	teamvite "github.com/benprew/teamvite"
@@ -11,10 +13,17 @@ import (
 )
 
 type playerShowParams struct {
-	Player *teamvite.Player
-	IsUser bool
-	Teams  []teamvite.PlayerTeam
-	Games  []*teamvite.Game
+	Player    *teamvite.Player
+	IsUser    bool
+	Teams     []teamvite.PlayerTeam
+	Games     []*teamvite.Game
+	Transfers []*teamvite.Transfer
+	// The user's calendar feeds by team ID
+	Feeds map[uint64]*teamvite.CalendarFeed
+	// The user's calendar feed of all their teams
+	PersonalFeed *teamvite.CalendarFeed
+	// Subscription urls of the feeds by feed ID, on the league's host
+	FeedURLs map[uint64]htmltemplate.URL
 }
 
 func (s *Server) playerShow() http.Handler {
@@ -30,7 +39,7 @@ func (s *Server) playerShow() http.Handler {
 
 		games, _, err := s.GameService.FindGames(
 			r.Context(),
-			teamvite.GameFilter{PlayerID: player.ID, Time: time.Now().Unix()})
+			teamvite.GameFilter{PlayerID: player.ID, OpenSeasons: true, Time: time.Now().Unix()})
 		if err != nil {
 			s.Error(w, r, err)
 			return
@@ -42,11 +51,52 @@ func (s *Server) playerShow() http.Handler {
 			Teams:  teams,
 			Games:  games,
 		}
+		if !templateParams.IsUser {
+			templateParams.Games = visibleGames(teams, games)
+		}
+		if templateParams.IsUser {
+			templateParams.Transfers, err = s.TeamService.FindTransfers(r.Context(), teamvite.TransferFilter{ToPlayerID: user.ID})
+			if err != nil {
+				s.Error(w, r, err)
+				return
+			}
+			feeds, err := s.CalendarFeedService.FindCalendarFeeds(r.Context())
+			if err != nil {
+				s.Error(w, r, err)
+				return
+			}
+			templateParams.Feeds = map[uint64]*teamvite.CalendarFeed{}
+			templateParams.FeedURLs = map[uint64]htmltemplate.URL{}
+			for _, f := range feeds {
+				templateParams.FeedURLs[f.ID] = FeedUrl(r.Context(), f)
+				if f.TeamID == 0 {
+					templateParams.PersonalFeed = f
+				} else {
+					templateParams.Feeds[f.TeamID] = f
+				}
+			}
+		}
 		log.Printf("playerShow: rendering template: %s\n", template)
 		s.RenderTemplate(w, r, template, templateParams)
 	})
 }
 
+// visibleGames removes the games of teams that aren't listed, because they're
+// hidden from the user, from another player's page.
+func visibleGames(teams []teamvite.PlayerTeam, games []*teamvite.Game) []*teamvite.Game {
+	visible := map[uint64]bool{}
+	for _, pt := range teams {
+		visible[pt.Team.ID] = true
+	}
+	var vGames []*teamvite.Game
+	for _, g := range games {
+		if visible[g.TeamID] {
+			vGames = append(vGames, g)
+		}
+	}
+	return vGames
+}
+
 func (s *Server) PlayerEdit() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user := teamvite.UserFromContext((r.Context()))
@@ -66,7 +116,7 @@ func (s *Server) PlayerEdit() http.Handler {
 
 		games, _, err := s.GameService.FindGames(
 			r.Context(),
-			teamvite.GameFilter{PlayerID: player.ID, Time: time.Now().Unix()})
+			teamvite.GameFilter{PlayerID: player.ID, OpenSeasons: true, Time: time.Now().Unix()})
 		if err != nil {
 			s.Error(w, r, err)
 			return
@@ -160,6 +210,16 @@ func (s *Server) PlayerUpdate() http.Handler {
 					pt.RemindSMS = true
 				}
 			}
+			pt.DefaultStatus = r.PostForm.Get(teamvite.DefaultStatusID(pt.Team.ID))
+			if !teamvite.ValidDefaultStatus(pt.DefaultStatus) {
+				s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid default status: %s", pt.DefaultStatus))
+				return
+			}
+			pt.Positions = strings.TrimSpace(r.PostForm.Get(teamvite.PositionsID(pt.Team.ID)))
+			if len(pt.Positions) > teamvite.MaxPositionsLength {
+				s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Positions must be %d characters or less.", teamvite.MaxPositionsLength))
+				return
+			}
 			log.Println(pt)
 			if err := s.PlayerService.UpdatePlayerTeam(r.Context(), &pt); err != nil {
 				s.Error(w, r, err)
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter allows up to limit requests per key in each window.
+type rateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	counts map[string]*rateCount
+}
+
+type rateCount struct {
+	n     int
+	start time.Time
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window, counts: map[string]*rateCount{}}
+}
+
+// Blocked reports whether key has used up its limit, without recording a
+// request.
+func (l *rateLimiter) Blocked(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, ok := l.counts[key]
+	return ok && time.Since(c.start) <= l.window && c.n >= l.limit
+}
+
+// Allow records a request for key and reports whether it is within the limit.
+func (l *rateLimiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	c, ok := l.counts[key]
+	if !ok || now.Sub(c.start) > l.window {
+		// drop expired windows so the map doesn't grow forever
+		for k, v := range l.counts {
+			if now.Sub(v.start) > l.window {
+				delete(l.counts, k)
+			}
+		}
+		c = &rateCount{start: now}
+		l.counts[key] = c
+	}
+	c.n++
+	return c.n <= l.limit
+}
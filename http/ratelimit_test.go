@@ -0,0 +1,23 @@
+package http
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(2, time.Hour)
+	if l.Blocked("a") {
+		t.Errorf("blocked before any requests")
+	}
+	if !l.Allow("a") || !l.Allow("a") {
+		t.Errorf("requests within the limit weren't allowed")
+	}
+	if !l.Blocked("a") || l.Allow("a") {
+		t.Errorf("request over the limit was allowed")
+	}
+	// checking doesn't count as a request
+	if l.Blocked("b") || l.Blocked("b") || l.Blocked("b") || !l.Allow("b") {
+		t.Errorf("other key was limited")
+	}
+}
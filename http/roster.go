@@ -0,0 +1,239 @@
+package http
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+
+	teamvite "github.com/benprew/teamvite"
+)
+
+// Largest roster file accepted for import
+const maxRosterSize = 1 << 20
+
+type rosterImportParams struct {
+	Team *teamvite.Team
+	Rows []rosterRow
+	CSV  string // sent back when the import is confirmed
+	// Number of rows that will be invited
+	Valid int
+}
+
+type rosterRow struct {
+	teamvite.RosterEntry
+	// Existing player with the entry's email
+	Existing *teamvite.Player
+	// Another player already using the entry's phone number
+	PhoneMatch *teamvite.Player
+}
+
+// teamRosterImport shows a preview of a roster CSV upload. When the preview
+// is confirmed each valid row is sent an invitation.
+func (s *Server) teamRosterImport() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		team := teamvite.TeamFromContext(ctx)
+		if !s.isManager(ctx, team) {
+			http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxRosterSize+4096)
+		var data string
+		if f, _, err := r.FormFile("roster"); err == nil {
+			b, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Unable to read roster file."))
+				return
+			}
+			data = string(b)
+		} else {
+			data = r.PostFormValue("csv")
+		}
+
+		entries, err := teamvite.ParseRoster(strings.NewReader(data))
+		if err == nil && len(entries) == 0 {
+			err = teamvite.Errorf(teamvite.EINVALID, "No players found in the roster file.")
+		}
+		if err != nil {
+			s.redirectWithResult(w, r, err, UrlFor(team, "edit"), "")
+			return
+		}
+
+		rows, err := s.checkRoster(r, team, entries)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+
+		if r.PostFormValue("confirm") == "" {
+			params := rosterImportParams{Team: team, Rows: rows, CSV: data}
+			for _, row := range rows {
+				if row.Problem == "" {
+					params.Valid++
+				}
+			}
+			s.RenderTemplate(w, r, teamvite.TemplateFromContext(ctx), params)
+			return
+		}
+
+		sent, skipped := 0, 0
+		for _, row := range rows {
+			if row.Problem != "" {
+				skipped++
+				continue
+			}
+			inv := teamvite.Invitation{
+				TeamID:    team.ID,
+				Email:     row.Email,
+				Name:      row.Name,
+				Phone:     row.Phone,
+				IsManager: row.IsManager,
+			}
+			if err := s.InvitationService.CreateInvitation(ctx, &inv); err != nil {
+				s.redirectWithResult(w, r, err, UrlFor(team, "edit"), "")
+				return
+			}
+			if err := s.sendInvitation(ctx, &inv); err != nil {
+				s.Error(w, r, err)
+				return
+			}
+			sent++
+		}
+		SetFlash(w, fmt.Sprintf("Sent %d invitations, skipped %d rows", sent, skipped))
+		http.Redirect(w, r, UrlFor(team, "edit"), http.StatusSeeOther)
+	})
+}
+
+// checkRoster flags roster entries that are duplicated in the file, already
+// on the team or already invited, and matches the rest to existing players.
+func (s *Server) checkRoster(r *http.Request, team *teamvite.Team, entries []teamvite.RosterEntry) ([]rosterRow, error) {
+	ctx := r.Context()
+	players, _, err := s.PlayerService.FindPlayers(ctx, teamvite.PlayerFilter{TeamID: &team.ID})
+	if err != nil {
+		return nil, err
+	}
+	onTeam := map[string]bool{}
+	for _, p := range players {
+		onTeam[strings.ToLower(p.Email)] = true
+	}
+
+	invs, err := s.InvitationService.FindInvitations(ctx, teamvite.InvitationFilter{
+		TeamID: team.ID, Statuses: []string{teamvite.InvitationPending}})
+	if err != nil {
+		return nil, err
+	}
+	invited := map[string]bool{}
+	for _, inv := range invs {
+		if !inv.Expired() {
+			invited[strings.ToLower(inv.Email)] = true
+		}
+	}
+
+	seen := map[string]int{}
+	rows := make([]rosterRow, 0, len(entries))
+	for _, e := range entries {
+		row := rosterRow{RosterEntry: e}
+		email := strings.ToLower(e.Email)
+		if row.Problem == "" {
+			if line, ok := seen[email]; ok {
+				row.Problem = fmt.Sprintf("Duplicate of line %d", line)
+			} else if onTeam[email] {
+				row.Problem = "Already on the team"
+			} else if invited[email] {
+				row.Problem = "Already invited"
+			}
+		}
+		if _, ok := seen[email]; !ok {
+			seen[email] = e.Line
+		}
+
+		if row.Problem == "" {
+			found, _, err := s.PlayerService.FindPlayers(ctx, teamvite.PlayerFilter{Email: e.Email, Limit: 1})
+			if err != nil {
+				return nil, err
+			}
+			if len(found) > 0 {
+				row.Existing = found[0]
+			}
+			if e.Phone != 0 {
+				found, _, err := s.PlayerService.FindPlayers(ctx, teamvite.PlayerFilter{Phone: e.Phone, Limit: 1})
+				if err != nil {
+					return nil, err
+				}
+				if len(found) > 0 && !strings.EqualFold(found[0].Email, e.Email) {
+					row.PhoneMatch = found[0]
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (s *Server) teamRosterCSV() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		team, players, ok := s.rosterForExport(w, r)
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv;charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", team.Name+".csv"))
+		cw := csv.NewWriter(w)
+		cw.Write(teamvite.RosterColumns)
+		for _, p := range players {
+			phone := ""
+			if p.Phone != 0 {
+				phone = teamvite.Telify(p.Phone)
+			}
+			cw.Write([]string{p.Name, p.Email, phone, strconv.FormatBool(p.IsManager)})
+		}
+		cw.Flush()
+	})
+}
+
+func (s *Server) teamRosterVCard() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		team, players, ok := s.rosterForExport(w, r)
+		if !ok {
+			return
+		}
+
+		filename := "views/team/roster.vcf.tmpl"
+		// parse as text/template to avoid html escaping
+		t := template.Must(template.New("roster.vcf.tmpl").Funcs(template.FuncMap{
+			"vcardEscape": vcardEscape,
+			"Telify":      teamvite.Telify,
+		}).ParseFS(views, filename))
+		w.Header().Set("Content-Type", "text/vcard;charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", team.Name+".vcf"))
+		t.ExecuteTemplate(w, "roster.vcf.tmpl", map[string]interface{}{"Team": team, "Players": players})
+	})
+}
+
+// Roster exports include contact info so are limited to managers.
+func (s *Server) rosterForExport(w http.ResponseWriter, r *http.Request) (*teamvite.Team, []*teamvite.Player, bool) {
+	team := teamvite.TeamFromContext(r.Context())
+	if !s.isManager(r.Context(), team) {
+		http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+		return nil, nil, false
+	}
+	players, _, err := s.PlayerService.FindPlayers(r.Context(), teamvite.PlayerFilter{TeamID: &team.ID})
+	if err != nil {
+		s.Error(w, r, err)
+		return nil, nil, false
+	}
+	return team, players, true
+}
+
+var vcardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\n", `\n`)
+
+func vcardEscape(s string) string {
+	return vcardEscaper.Replace(s)
+}
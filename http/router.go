@@ -61,7 +61,13 @@ func buildRouteInfo(path string) (routePath, error) {
 	if len(pieces) == 2 {
 		rStr = routePath{pieces[1], 0, "list", ""}
 	} else if len(pieces) == 3 {
-		rStr = routePath{pieces[1], 0, pieces[2], ""}
+		// /team/123 is the model itself (ex. PATCH /team/123), /team/new is an
+		// action
+		if id, err := strconv.ParseUint(pieces[2], 10, 32); err == nil {
+			rStr = routePath{pieces[1], id, "show", ""}
+		} else {
+			rStr = routePath{pieces[1], 0, pieces[2], ""}
+		}
 	} else if len(pieces) == 4 {
 		if id, err := strconv.ParseUint(pieces[2], 10, 32); err != nil {
 			return routePath{}, fmt.Errorf("id invalid: %s", pieces[2])
@@ -22,6 +22,7 @@ func (s *Server) routes() http.Handler {
 	mux.HandleFunc("GET /robots.txt", serveStatic)
 	mux.HandleFunc("GET /css/all.css", serveStatic)
 	mux.HandleFunc("GET /css/marx.min.css", serveStatic)
+	mux.HandleFunc("GET /js/passkey.js", serveStatic)
 	mux.Handle("GET /", s.routeWithMiddleware(s.root()))
 
 	mux.Handle("GET /sms", s.SMS())
@@ -30,30 +31,99 @@ func (s *Server) routes() http.Handler {
 
 	mux.Handle("GET /user/login", s.userLogin())
 	mux.Handle("POST /user/login", s.userLoginPost())
+	mux.Handle("POST /user/login_link", s.userLoginLinkPost())
+	mux.Handle("GET /user/login_link", s.userLoginLink())
+	mux.Handle("POST /user/redeem_login_link", s.userLoginLinkRedeem())
+	mux.Handle("GET /user/signup", s.userSignup())
+	mux.Handle("POST /user/signup", s.userSignupPost())
+	mux.Handle("GET /user/verify", s.userVerify())
+	mux.Handle("GET /user/welcome", s.routeWithMiddleware(s.userWelcome()))
+	mux.Handle("GET /user/forgot_password", s.userForgotPassword())
+	mux.Handle("POST /user/forgot_password", s.userForgotPasswordPost())
+	mux.Handle("GET /user/reset_password", s.userResetPassword())
+	mux.Handle("POST /user/reset_password", s.userResetPasswordPost())
 	mux.Handle("GET /user/logout", s.routeWithMiddleware(s.userLogout()))
+	mux.Handle("GET /user/two_factor", s.routeWithMiddleware(s.userTwoFactor()))
+	mux.Handle("POST /user/two_factor", s.routeWithMiddleware(s.userTwoFactorPost()))
+	mux.Handle("POST /user/disable_two_factor", s.routeWithMiddleware(s.userDisableTwoFactor()))
+	mux.Handle("GET /user/verify_two_factor", s.routeWithMiddleware(s.userVerifyTwoFactor()))
+	mux.Handle("POST /user/verify_two_factor", s.routeWithMiddleware(s.userVerifyTwoFactorPost()))
+	mux.Handle("GET /user/passkeys", s.routeWithMiddleware(s.userPasskeys()))
+	mux.Handle("POST /user/passkeys", s.routeWithMiddleware(s.userPasskeyRegister()))
+	mux.Handle("POST /user/passkey_options", s.routeWithMiddleware(s.userPasskeyRegisterOptions()))
+	mux.Handle("POST /user/delete_passkey", s.routeWithMiddleware(s.userDeletePasskey()))
+	mux.Handle("POST /user/passkey_login_options", s.userPasskeyLoginOptions())
+	mux.Handle("POST /user/passkey_login", s.userPasskeyLogin())
 
 	mux.Handle("GET /player/{id}/show", s.routeWithMiddleware(s.playerShow()))
 	mux.Handle("GET /player/{id}/edit", s.routeWithMiddleware(s.PlayerEdit()))
 	mux.Handle("POST /player/{id}/edit", s.routeWithMiddleware(s.PlayerUpdate()))
 	mux.Handle("PATCH /player/{id}/edit", s.routeWithMiddleware(s.PlayerUpdate()))
+	mux.Handle("GET /player/{id}/calendar.ics", s.routeWithMiddleware(s.playerCalendar()))
+	mux.Handle("POST /player/{id}/calendar_feed", s.routeWithMiddleware(s.playerCreateCalendarFeed()))
+	mux.Handle("POST /player/{id}/update_calendar_feed", s.routeWithMiddleware(s.playerUpdateCalendarFeed()))
+	mux.Handle("POST /player/{id}/revoke_calendar_feed", s.routeWithMiddleware(s.playerRevokeCalendarFeed()))
+	mux.Handle("GET /player/{id}/sessions", s.routeWithMiddleware(s.playerSessions()))
+	mux.Handle("POST /player/{id}/revoke_session", s.routeWithMiddleware(s.playerRevokeSession()))
+	mux.Handle("POST /player/{id}/revoke_other_sessions", s.routeWithMiddleware(s.playerRevokeOtherSessions()))
 
 	mux.Handle("GET /team", s.routeWithMiddleware(s.teamList()))
 	mux.Handle("GET /team/{id}/show", s.routeWithMiddleware(s.teamShow()))
 	mux.Handle("GET /team/{id}/edit", s.routeWithMiddleware(s.teamEdit()))
-	mux.Handle("POST /team/{id}/add_player", s.routeWithMiddleware(s.teamAddPlayer()))
+	mux.Handle("POST /team/{id}/edit", s.routeWithMiddleware(s.teamUpdate()))
+	mux.Handle("PATCH /team/{id}", s.routeWithMiddleware(s.teamUpdate()))
+	mux.Handle("POST /team/{id}/invite", s.routeWithMiddleware(s.teamInvite()))
+	mux.Handle("POST /team/{id}/resend_invitation", s.routeWithMiddleware(s.teamResendInvitation()))
+	mux.Handle("POST /team/{id}/revoke_invitation", s.routeWithMiddleware(s.teamRevokeInvitation()))
+	mux.Handle("GET /team/{id}/join", s.routeWithMiddleware(s.teamJoin()))
+	mux.Handle("POST /team/{id}/join", s.routeWithMiddleware(s.teamJoinRequest()))
+	mux.Handle("GET /team/{id}/join.png", s.routeWithMiddleware(s.teamJoinQRCode()))
+	mux.Handle("POST /team/{id}/approve_join", s.routeWithMiddleware(s.teamRespondToJoinRequest(true)))
+	mux.Handle("POST /team/{id}/reject_join", s.routeWithMiddleware(s.teamRespondToJoinRequest(false)))
+	mux.Handle("POST /team/{id}/import", s.routeWithMiddleware(s.teamRosterImport()))
+	mux.Handle("GET /team/{id}/roster.csv", s.routeWithMiddleware(s.teamRosterCSV()))
+	mux.Handle("GET /team/{id}/roster.vcf", s.routeWithMiddleware(s.teamRosterVCard()))
 	mux.Handle("POST /team/{id}/remove_player", s.routeWithMiddleware(s.teamRemovePlayer()))
+	mux.Handle("POST /team/{id}/announce", s.routeWithMiddleware(s.teamAnnounce()))
+	mux.Handle("POST /team/{id}/update_player", s.routeWithMiddleware(s.teamUpdateRosterPlayer()))
+	mux.Handle("POST /team/{id}/set_manager", s.routeWithMiddleware(s.teamSetManager()))
+	mux.Handle("POST /team/{id}/transfer", s.routeWithMiddleware(s.teamTransfer()))
+	mux.Handle("POST /team/{id}/accept_transfer", s.routeWithMiddleware(s.teamRespondToTransfer(true)))
+	mux.Handle("POST /team/{id}/decline_transfer", s.routeWithMiddleware(s.teamRespondToTransfer(false)))
+	mux.Handle("POST /team/{id}/start_season", s.routeWithMiddleware(s.teamStartSeason()))
+	mux.Handle("POST /team/{id}/confirm_season", s.routeWithMiddleware(s.teamRespondToSeason(true)))
+	mux.Handle("POST /team/{id}/leave_season", s.routeWithMiddleware(s.teamRespondToSeason(false)))
 	mux.Handle("GET /team/{id}/calendar.ics", s.routeWithMiddleware(s.teamCalendar()))
 	mux.Handle("POST /team", s.routeWithMiddleware(s.teamCreate()))
 
+	// Secret calendar feeds, calendar apps don't send a session
+	mux.Handle("GET /feed/{token}/calendar.ics", s.calendarFeed())
+
+	// Links from invitation emails
+	mux.Handle("GET /invitation/accept", s.routeWithMiddleware(s.invitationAccept()))
+	mux.Handle("GET /invitation/decline", s.routeWithMiddleware(s.invitationDecline()))
+
 	// Handles game responses.  Done as a GET so you can follow links in email
 	mux.Handle("GET /game/{id}/show", s.routeWithMiddleware(s.gameShow()))
+	mux.Handle("POST /game/{id}/message", s.routeWithMiddleware(s.gameMessageCreate()))
 	mux.Handle("POST /game", s.routeWithMiddleware(s.GameCreate()))
 
+	mux.Handle("GET /search", s.routeWithMiddleware(s.search()))
+
+	mux.Handle("GET /division", s.routeWithMiddleware(s.DivisionList()))
+	mux.Handle("POST /division", s.routeWithMiddleware(s.divisionCreate()))
+	mux.Handle("GET /division/{id}/show", s.routeWithMiddleware(s.divisionShow()))
+	mux.Handle("POST /division/{id}/edit", s.routeWithMiddleware(s.divisionUpdate()))
+	mux.Handle("PATCH /division/{id}", s.routeWithMiddleware(s.divisionUpdate()))
+	mux.Handle("DELETE /division/{id}", s.routeWithMiddleware(s.divisionArchive()))
+
 	// JSON APIs
 	mux.Handle("GET /season", s.routeWithMiddleware(s.SeasonList()))
-	mux.Handle("GET /division", s.routeWithMiddleware(s.DivisionList()))
+	mux.Handle("POST /season", s.routeWithMiddleware(s.seasonCreate()))
+	mux.Handle("GET /season/{id}/show", s.routeWithMiddleware(s.seasonShow()))
+	mux.Handle("POST /season/{id}/close", s.routeWithMiddleware(s.seasonClose()))
 
-	return mux
+	return s.organizationMiddleware(mux)
 }
 
 func (s *Server) routeWithMiddleware(handler http.Handler) http.Handler {
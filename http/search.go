@@ -0,0 +1,40 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	teamvite "github.com/benprew/teamvite"
+)
+
+type searchParams struct {
+	Q       string
+	Results []*teamvite.SearchResult
+}
+
+// Searches teams, divisions and players. JSON requests are used for
+// typeahead, so partial words match.
+func (s *Server) search() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		results, err := s.SearchService.Search(r.Context(), q, limit)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+
+		switch r.Header.Get("Content-type") {
+		case JSON:
+			w.Header().Set("Content-Type", JSON)
+			json.NewEncoder(w).Encode(results)
+		default:
+			templateParams := searchParams{
+				Q:       q,
+				Results: results,
+			}
+			s.RenderTemplate(w, r, "views/search/list.tmpl", templateParams)
+		}
+	})
+}
@@ -2,14 +2,33 @@ package http
 
 import (
 	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
+	"time"
 
 	teamvite "github.com/benprew/teamvite"
 )
 
-type Season struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+// dates of the season create form
+const seasonDateFormat = "2006-01-02"
+
+type seasonListParams struct {
+	Seasons []*teamvite.Season
+	Current *teamvite.Season
+	IsAdmin bool
+}
+
+type seasonShowParams struct {
+	Season    *teamvite.Season  `json:"season"`
+	Divisions []*seasonDivision `json:"divisions"`
+	IsAdmin   bool              `json:"-"`
+}
+
+// teams of a season grouped by their division
+type seasonDivision struct {
+	Name  string           `json:"name"`
+	Teams []*teamvite.Team `json:"teams"`
 }
 
 func (s *Server) SeasonList() http.Handler {
@@ -21,7 +40,120 @@ func (s *Server) SeasonList() http.Handler {
 			return
 		}
 
-		w.Header().Set("Content-Type", JSON)
-		json.NewEncoder(w).Encode(seasons)
+		switch r.Header.Get("Content-type") {
+		case JSON:
+			w.Header().Set("Content-Type", JSON)
+			json.NewEncoder(w).Encode(seasons)
+		default:
+			current, err := s.SeasonService.CurrentSeason(r.Context())
+			if err != nil && teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+				s.Error(w, r, err)
+				return
+			}
+			templateParams := seasonListParams{
+				Seasons: seasons,
+				Current: current,
+				IsAdmin: isAdmin(r),
+			}
+			err = s.RenderTemplate(w, r, "views/season/list.tmpl", templateParams)
+			if err != nil {
+				log.Println(err)
+				s.Error(w, r, err)
+				return
+			}
+		}
+	})
+}
+
+// Shows the divisions and teams playing in the season.
+func (s *Server) seasonShow() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		season := teamvite.SeasonFromContext(ctx)
+		teams, _, err := s.TeamService.FindTeams(ctx, teamvite.TeamFilter{SeasonID: uint64(season.ID)})
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+
+		params := seasonShowParams{
+			Season:    season,
+			Divisions: []*seasonDivision{},
+			IsAdmin:   isAdmin(r),
+		}
+		divisions := map[string]*seasonDivision{}
+		for _, t := range teams {
+			d, ok := divisions[t.DivisionName]
+			if !ok {
+				d = &seasonDivision{Name: t.DivisionName}
+				divisions[t.DivisionName] = d
+				params.Divisions = append(params.Divisions, d)
+			}
+			d.Teams = append(d.Teams, t)
+		}
+
+		switch r.Header.Get("Content-type") {
+		case JSON:
+			w.Header().Set("Content-Type", JSON)
+			json.NewEncoder(w).Encode(params)
+		default:
+			s.RenderTemplate(w, r, teamvite.TemplateFromContext(ctx), params)
+		}
+	})
+}
+
+// curl -i -X POST --silent \
+// http://teamvitedev.com:8080/season \
+// -H 'Content-Type: application/json' \
+// --data '{"name":"2022-winter","start_on":"2022-01-03T00:00:00Z","end_on":"2022-03-27T00:00:00Z"}'
+func (s *Server) seasonCreate() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var season teamvite.Season
+		switch r.Header.Get("Content-type") {
+		case JSON:
+			if err := json.NewDecoder(r.Body).Decode(&season); err != nil {
+				s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid JSON body."))
+				return
+			}
+			if err := s.SeasonService.CreateSeason(r.Context(), &season); err != nil {
+				s.Error(w, r, err)
+				return
+			}
+			w.Header().Set("Content-Type", JSON)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(season)
+		default:
+			if err := r.ParseForm(); err != nil {
+				s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+				return
+			}
+			season.Name = r.PostForm.Get("name")
+			// blank or bad dates are left zero and rejected by CreateSeason
+			season.StartOn, _ = time.Parse(seasonDateFormat, r.PostForm.Get("start_on"))
+			season.EndOn, _ = time.Parse(seasonDateFormat, r.PostForm.Get("end_on"))
+			err := s.SeasonService.CreateSeason(r.Context(), &season)
+			url := "/season"
+			if err == nil {
+				url = UrlFor(&season, "show")
+			}
+			s.redirectWithResult(w, r, err, url, fmt.Sprintf("Created %s", season.Name))
+		}
+	})
+}
+
+func (s *Server) seasonClose() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		season := teamvite.SeasonFromContext(r.Context())
+		closed, err := s.SeasonService.CloseSeason(r.Context(), uint64(season.ID))
+		if r.Header.Get("Content-type") == JSON {
+			if err != nil {
+				s.Error(w, r, err)
+				return
+			}
+			w.Header().Set("Content-Type", JSON)
+			json.NewEncoder(w).Encode(closed)
+			return
+		}
+		s.redirectWithResult(w, r, err, UrlFor(season, "show"), fmt.Sprintf("Closed %s", season.Name))
 	})
 }
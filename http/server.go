@@ -13,6 +13,9 @@ import (
 
 const JSON = "application/json"
 const SESSION_KEY = "teamvite-session"
+
+// query param of the action token in reminder links
+const ACTION_TOKEN_KEY = "token"
 const ShutdownTimeout = 1 * time.Second
 
 type Server struct {
@@ -24,7 +27,23 @@ type Server struct {
 	DivisionService teamvite.DivisionService
 	SeasonService   teamvite.SeasonService
 
+	OrganizationService teamvite.OrganizationService
+
+	AnnouncementService teamvite.AnnouncementService
+	InvitationService   teamvite.InvitationService
+	JoinRequestService  teamvite.JoinRequestService
+	CalendarFeedService teamvite.CalendarFeedService
+	SearchService       teamvite.SearchService
+	SignupService       teamvite.SignupService
+
+	PasswordResetService teamvite.PasswordResetService
+	ActionTokenService   teamvite.ActionTokenService
+	TwoFactorService     teamvite.TwoFactorService
+	PasskeyService       teamvite.PasskeyService
+
 	SessionService teamvite.SessionService
+	MailService    teamvite.MailService
+	SMSService     teamvite.SMSService
 
 	// bind address and domainname for the listener
 	Addr   string
@@ -117,31 +136,65 @@ func (s *Server) routeModelMiddleware(next http.Handler) http.Handler {
 				s.Error(w, r, err)
 				return
 			}
-			r = r.WithContext(teamvite.NewContextWithGame(r.Context(), routeInfo.Template, game))
+			// games of hidden teams are hidden too, except to the player of
+			// the reminder link being followed
+			team, err := s.TeamService.FindTeamByID(r.Context(), game.TeamID)
+			if token := r.URL.Query().Get(ACTION_TOKEN_KEY); teamvite.ErrorCode(err) == teamvite.ENOTFOUND && token != "" {
+				team, err = s.findTeamForToken(r.Context(), token, game)
+			}
+			if err != nil {
+				s.Error(w, r, err)
+				return
+			}
+			ctx := teamvite.NewContextWithTeam(r.Context(), routeInfo.Template, team)
+			r = r.WithContext(teamvite.NewContextWithGame(ctx, routeInfo.Template, game))
 		} else if routeInfo.ModelType == "division" {
-			// we only allow division list, there aren't division show/edit/etc pages
-			r = r.WithContext(teamvite.NewContextWithDivision(r.Context(), routeInfo.Template, &teamvite.Division{}))
+			division := &teamvite.Division{}
+			if routeInfo.ID != 0 {
+				division, err = s.DivisionService.FindDivisionByID(r.Context(), routeInfo.ID)
+				if err != nil {
+					s.Error(w, r, err)
+					return
+				}
+			}
+			r = r.WithContext(teamvite.NewContextWithDivision(r.Context(), routeInfo.Template, division))
+		} else if routeInfo.ModelType == "season" && routeInfo.ID != 0 {
+			id := routeInfo.ID
+			seasons, _, err := s.SeasonService.FindSeasons(r.Context(), teamvite.SeasonFilter{ID: &id})
+			if err != nil {
+				s.Error(w, r, err)
+				return
+			}
+			if len(seasons) == 0 {
+				s.Error(w, r, teamvite.Errorf(teamvite.ENOTFOUND, "Season not found: %d", id))
+				return
+			}
+			r = r.WithContext(teamvite.NewContextWithSeason(r.Context(), routeInfo.Template, seasons[0]))
 		}
 
 		next.ServeHTTP(w, r)
 	})
 }
 
+// findTeamForToken finds the game's team as the player of an action token
+// for the game. The player only stands in for the user for this lookup, the
+// token is checked again by the action it is for.
+func (s *Server) findTeamForToken(ctx context.Context, token string, game *teamvite.Game) (*teamvite.Team, error) {
+	t, err := s.ActionTokenService.FindActionToken(ctx, token, game.ID, teamvite.ActionRSVP)
+	if err != nil {
+		return nil, err
+	}
+	return s.TeamService.FindTeamByID(teamvite.NewContextWithUser(ctx, &teamvite.Player{ID: t.PlayerID}), game.TeamID)
+}
+
 func (s *Server) sessionMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var user *teamvite.Player
-		var sids []string
-
-		// try loading user from request token
-		sid := sidFromRequest(r)
-		if sid != "" {
-			sids = append(sids, sid)
-		}
-		// try loading user from cookie
-		sids = append(sids, SidsFromCookie(r, SESSION_KEY)[:]...)
+		var session *teamvite.Session
 
-		for _, sid := range sids {
-			// exit once we have a user, which may be from the token above
+		// sessions only come from the cookie, links in emails use action tokens
+		for _, sid := range SidsFromCookie(r, SESSION_KEY) {
+			// exit once we have a user
 			if user != nil {
 				break
 			}
@@ -162,29 +215,30 @@ func (s *Server) sessionMiddleware(next http.Handler) http.Handler {
 				s.Error(w, r, err)
 				return
 			}
+			session = &sess
+			// once a minute is precise enough for the sessions page
+			if time.Since(sess.LastSeenOn) > time.Minute {
+				if err := s.SessionService.Touch(sid); err != nil {
+					log.Printf("[WARN] Failed to record session activity: %s\n", err)
+				}
+			}
 		}
-		r = r.WithContext(teamvite.NewContextWithUser(r.Context(), user))
+		if session != nil && !session.TwoFactor && teamvite.CONFIG.RequireTwoFactor {
+			if redirect, err := s.twoFactorRequired(r, session.PlayerID); err != nil {
+				s.Error(w, r, err)
+				return
+			} else if redirect != "" {
+				SetFlash(w, "Team managers and league admins must use two-factor authentication.")
+				http.Redirect(w, r, redirect, http.StatusFound)
+				return
+			}
+		}
+		ctx := teamvite.NewContextWithSession(r.Context(), session)
+		r = r.WithContext(teamvite.NewContextWithUser(ctx, user))
 		next.ServeHTTP(w, r)
 	})
 }
 
-func sidFromRequest(r *http.Request) string {
-	if r == nil || r.URL == nil {
-		log.Println("[ERROR] sidFromRequest: nil request")
-		return ""
-	}
-	query := r.URL.Query()
-	if query == nil {
-		log.Println("[ERROR] sidFromRequest: nil query")
-		return ""
-	}
-	sid := query.Get(SESSION_KEY)
-	if sid != "" {
-		log.Printf("[INFO] sidFromRequest: Found session_id from request: %s\n", sid)
-	}
-	return sid
-}
-
 // lookup of application error codes to HTTP status codes.
 var codes = map[string]int{
 	teamvite.ECONFLICT:       http.StatusConflict,
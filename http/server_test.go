@@ -0,0 +1,60 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	teamvite "github.com/benprew/teamvite"
+	"github.com/benprew/teamvite/sqlite"
+)
+
+func TestReminderLinkHiddenTeam(t *testing.T) {
+	db := sqlite.Open(":memory:")
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	schema, err := os.ReadFile("../db/create.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(string(schema) + `
+		insert into divisions (id, name) values (1, 'm1');
+		insert into seasons (id, name) values (1, '2026-fall');
+		insert into teams (id, name, division_id, privacy) values (1, 'Blue', 1, 'hidden');
+		insert into players (id, name, email) values (1, 'Member', 'm@example.com');
+		insert into players_teams (player_id, team_id) values (1, 1);
+		insert into games (id, team_id, season_id, time) values (1, 1, 1, 0);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewServer()
+	s.GameService = sqlite.NewGameService(db)
+	s.TeamService = sqlite.NewTeamService(db)
+	s.ActionTokenService = sqlite.NewActionTokenService(db)
+	token, err := s.ActionTokenService.CreateActionToken(context.Background(), 1, 1, teamvite.ActionRSVP)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var team *teamvite.Team
+	handler := s.routeModelMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		team = teamvite.TeamFromContext(r.Context())
+	}))
+
+	// the link's token finds the hidden team without a session
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/game/1/show?status=Y&token="+token.Token, nil))
+	if team == nil || team.ID != 1 {
+		t.Errorf("with token: team = %v, status %d; want team 1", team, w.Code)
+	}
+
+	team = nil
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/game/1/show?status=Y&token=wrong", nil))
+	if team != nil || w.Code != http.StatusNotFound {
+		t.Errorf("bad token: team = %v, status %d; want not found", team, w.Code)
+	}
+}
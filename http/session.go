@@ -158,3 +158,76 @@ func (s *Server) setSession(w http.ResponseWriter, session teamvite.Session) {
 	}
 	http.SetCookie(w, cookie)
 }
+
+type playerSessionsParams struct {
+	Player   *teamvite.Player
+	Sessions []teamvite.Session
+	// Key of the session viewing the page
+	Current string
+}
+
+// Lists where the player is signed in, so they can sign out devices they
+// don't recognize.
+func (s *Server) playerSessions() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := teamvite.UserFromContext(r.Context())
+		player := teamvite.PlayerFromContext(r.Context())
+		if user == nil || user.ID != player.ID {
+			s.Error(w, r, teamvite.Errorf(teamvite.EUNAUTHORIZED, "You can only see your own sessions."))
+			return
+		}
+		sessions, err := s.SessionService.FindSessions(player.ID)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		params := playerSessionsParams{Player: player, Sessions: sessions}
+		if current := teamvite.SessionFromContext(r.Context()); current != nil {
+			params.Current = current.Key()
+		}
+		s.RenderTemplate(w, r, teamvite.TemplateFromContext(r.Context()), params)
+	})
+}
+
+func (s *Server) playerRevokeSession() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := teamvite.UserFromContext(r.Context())
+		player := teamvite.PlayerFromContext(r.Context())
+		if user == nil || user.ID != player.ID {
+			s.Error(w, r, teamvite.Errorf(teamvite.EUNAUTHORIZED, "You can only sign out your own sessions."))
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, err)
+			return
+		}
+
+		key := r.PostForm.Get("key")
+		err := s.SessionService.RevokeSession(player.ID, key)
+		if teamvite.ErrorCode(err) == teamvite.ENOTFOUND {
+			SetFlash(w, teamvite.ErrorMessage(err))
+			http.Redirect(w, r, UrlFor(player, "sessions"), http.StatusFound)
+			return
+		}
+		if current := teamvite.SessionFromContext(r.Context()); err == nil && current != nil && current.Key() == key {
+			// they signed out this browser
+			s.logout(w, r)
+			return
+		}
+		s.redirectWithResult(w, r, err, UrlFor(player, "sessions"), "Signed out the session.")
+	})
+}
+
+func (s *Server) playerRevokeOtherSessions() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := teamvite.UserFromContext(r.Context())
+		player := teamvite.PlayerFromContext(r.Context())
+		session := teamvite.SessionFromContext(r.Context())
+		if user == nil || session == nil || user.ID != player.ID {
+			s.Error(w, r, teamvite.Errorf(teamvite.EUNAUTHORIZED, "You can only sign out your own sessions."))
+			return
+		}
+		err := s.SessionService.RevokeOthers(player.ID, session.ID)
+		s.redirectWithResult(w, r, err, UrlFor(player, "sessions"), "Signed out everywhere else.")
+	})
+}
@@ -0,0 +1,182 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	teamvite "github.com/benprew/teamvite"
+)
+
+// each signup sends mail, so limit them per IP
+var signupLimiter = newRateLimiter(10, time.Hour)
+
+type welcomeParams struct {
+	Player    *teamvite.Player
+	Teams     []teamvite.PlayerTeam
+	Divisions []*teamvite.Division
+}
+
+func (s *Server) userSignup() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.RenderTemplate(w, r, "views/user/signup.tmpl", nil)
+	})
+}
+
+// The account isn't created until the emailed link is followed.
+func (s *Server) userSignupPost() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !signupLimiter.Allow(RequestIP(r).String()) {
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			return
+		}
+
+		signup := teamvite.Signup{
+			Name:     r.PostForm.Get("name"),
+			Email:    r.PostForm.Get("email"),
+			Password: r.PostForm.Get("password"),
+		}
+		if phone := strings.TrimSpace(r.PostForm.Get("phone")); phone != "" {
+			if signup.Phone = teamvite.UnTelify(phone); signup.Phone == -1 {
+				SetFlash(w, "Invalid phone number, must be 10 digits")
+				http.Redirect(w, r, "/user/signup", http.StatusFound)
+				return
+			}
+		}
+
+		// the reply is the same whether or not the email has an account, so
+		// signing up doesn't reveal who has one
+		err := s.SignupService.CreateSignup(r.Context(), &signup)
+		switch teamvite.ErrorCode(err) {
+		case "":
+			err = s.sendVerification(r.Context(), &signup)
+		case teamvite.ECONFLICT:
+			err = s.sendExistingAccount(r.Context(), &signup)
+		default:
+			s.redirectWithResult(w, r, err, "/user/signup", "")
+			return
+		}
+		if err != nil {
+			log.Printf("[ERROR] sending signup mail to %s: %s", signup.Email, err)
+			s.Error(w, r, err)
+			return
+		}
+		s.redirectWithResult(w, r, nil, "/user/login",
+			fmt.Sprintf("Almost done! Check your email at %s to finish signing up.", signup.Email))
+	})
+}
+
+// Following the emailed link creates the account and logs the player in.
+func (s *Server) userVerify() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		player, err := s.SignupService.VerifySignup(r.Context(), r.URL.Query().Get("token"))
+		if err != nil {
+			s.redirectWithResult(w, r, err, "/user/signup", "")
+			return
+		}
+
+		s.logIn(w, r, player, "/user/welcome", fmt.Sprintf("Welcome %s, your account is ready.", player.Name))
+	})
+}
+
+// The landing page for new players, pointing them to their teams or to
+// finding or creating one.
+func (s *Server) userWelcome() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := s.GetUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/user/login", http.StatusFound)
+			return
+		}
+
+		// the user's own teams
+		ctx := teamvite.NewContextWithPlayer(r.Context(), "views/user/welcome.tmpl", user)
+		teams, err := s.PlayerService.Teams(ctx)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		archived := false
+		divisions, _, err := s.DivisionService.FindDivisions(r.Context(), teamvite.DivisionFilter{Archived: &archived})
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+
+		s.RenderTemplate(w, r, teamvite.TemplateFromContext(ctx), welcomeParams{
+			Player:    user,
+			Teams:     teams,
+			Divisions: divisions,
+		})
+	})
+}
+
+var verificationMailTemplate = template.Must(template.New("verification").Parse(`
+Dear {{ .Signup.Name }},<br>
+Follow this link to finish creating your account:
+<a href="{{ .VerifyURL }}">{{ .VerifyURL }}</a><br>
+The link expires on {{ .Signup.ExpiresOn.Format "Mon Jan 2 3:04PM" }}. If you didn't sign up you can
+ignore this email.<br>
+
+Thank you for using {{ .League }}!
+`))
+
+func (s *Server) sendVerification(ctx context.Context, signup *teamvite.Signup) error {
+	league := teamvite.OrganizationFromContext(ctx).Name
+	var body bytes.Buffer
+	err := verificationMailTemplate.Execute(&body, map[string]interface{}{
+		"League": league,
+		"Signup": signup,
+		"VerifyURL": fmt.Sprintf("https://%s/user/verify?token=%s",
+			serverName(ctx), url.QueryEscape(signup.Token)),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.MailService.SendMail(ctx, &teamvite.Mail{
+		To:      []string{signup.Email},
+		Subject: "Finish signing up for " + league,
+		Body:    body.String(),
+	})
+}
+
+var existingAccountMailTemplate = template.Must(template.New("existing_account").Parse(`
+Hello,<br>
+Someone tried to sign up for {{ .League }} with this email, but you already have an
+account. <a href="{{ .LoginURL }}">Log in</a> instead, or reset your password
+from the login page if you've forgotten it. If it wasn't you, you can ignore
+this email.<br>
+
+Thank you for using {{ .League }}!
+`))
+
+// sendExistingAccount tells the owner of an email that already has an
+// account, in place of a verification link.
+func (s *Server) sendExistingAccount(ctx context.Context, signup *teamvite.Signup) error {
+	league := teamvite.OrganizationFromContext(ctx).Name
+	var body bytes.Buffer
+	err := existingAccountMailTemplate.Execute(&body, map[string]interface{}{
+		"League":   league,
+		"LoginURL": fmt.Sprintf("https://%s/user/login", serverName(ctx)),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.MailService.SendMail(ctx, &teamvite.Mail{
+		To:      []string{signup.Email},
+		Subject: fmt.Sprintf("You already have a %s account", league),
+		Body:    body.String(),
+	})
+}
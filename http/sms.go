@@ -22,7 +22,8 @@ func (s *Server) SMS() http.Handler {
 			return
 		}
 
-		ctx := teamvite.NewContextWithPlayer(r.Context(), "", player)
+		// the reply comes from the player's phone, so act as the player
+		ctx := teamvite.NewContextWithUser(teamvite.NewContextWithPlayer(r.Context(), "", player), player)
 
 		nextGame, err := s.PlayerService.NextRemindedGame(ctx, player.ID)
 		if err != nil {
@@ -0,0 +1,89 @@
+// Passkey (WebAuthn) registration and login. The server sends and receives
+// binary fields as unpadded base64url.
+(function () {
+  function decode(s) {
+    s = s.replace(/-/g, "+").replace(/_/g, "/");
+    return Uint8Array.from(atob(s), function (c) { return c.charCodeAt(0); });
+  }
+
+  function encode(buf) {
+    var s = String.fromCharCode.apply(null, new Uint8Array(buf));
+    return btoa(s).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+  }
+
+  function post(url, body) {
+    return fetch(url, {
+      method: "POST",
+      headers: { "Content-Type": "application/json", "Accept": "application/json" },
+      body: JSON.stringify(body || {}),
+    }).then(function (resp) {
+      return resp.json().then(function (data) {
+        if (!resp.ok) {
+          throw new Error(data.error || "Something went wrong, please try again.");
+        }
+        return data;
+      });
+    });
+  }
+
+  function showError(err) {
+    var el = document.getElementById("passkey-error");
+    if (el) {
+      el.textContent = err.name === "NotAllowedError" ? "The passkey request was cancelled." : err.message;
+    }
+  }
+
+  function addPasskey(event) {
+    event.preventDefault();
+    var name = event.target.elements.name.value;
+    var challenge;
+    post("/user/passkey_options").then(function (options) {
+      challenge = options.challenge;
+      options.challenge = decode(options.challenge);
+      options.user.id = decode(options.user.id);
+      options.excludeCredentials.forEach(function (c) { c.id = decode(c.id); });
+      return navigator.credentials.create({ publicKey: options });
+    }).then(function (cred) {
+      return post("/user/passkeys", {
+        name: name,
+        challenge: challenge,
+        client_data_json: encode(cred.response.clientDataJSON),
+        attestation_object: encode(cred.response.attestationObject),
+      });
+    }).then(function () {
+      window.location.reload();
+    }).catch(showError);
+  }
+
+  function logIn(event) {
+    event.preventDefault();
+    var challenge;
+    post("/user/passkey_login_options").then(function (options) {
+      challenge = options.challenge;
+      options.challenge = decode(options.challenge);
+      return navigator.credentials.get({ publicKey: options });
+    }).then(function (cred) {
+      return post("/user/passkey_login", {
+        challenge: challenge,
+        credential_id: encode(cred.rawId),
+        client_data_json: encode(cred.response.clientDataJSON),
+        authenticator_data: encode(cred.response.authenticatorData),
+        signature: encode(cred.response.signature),
+        user_handle: cred.response.userHandle ? encode(cred.response.userHandle) : "",
+      });
+    }).then(function (data) {
+      window.location = data.redirect;
+    }).catch(showError);
+  }
+
+  document.addEventListener("DOMContentLoaded", function () {
+    var supported = !!window.PublicKeyCredential;
+    document.querySelectorAll(".passkey").forEach(function (el) {
+      el.hidden = !supported;
+    });
+    var add = document.getElementById("passkey-add");
+    if (add) { add.addEventListener("submit", addPasskey); }
+    var login = document.getElementById("passkey-login");
+    if (login) { login.addEventListener("click", logIn); }
+  });
+})();
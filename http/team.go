@@ -1,52 +1,143 @@
 package http
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	htmltemplate "html/template"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
-	"text/template"
 	"time"
 
 	teamvite "github.com/benprew/teamvite"
 )
 
 type teamShowParams struct {
-	Team      *teamvite.Team
-	Players   []*teamvite.Player
-	Games     []*teamvite.Game
-	IsManager bool
+	Team          *teamvite.Team
+	Players       []*teamvite.Player
+	Games         []*teamvite.Game
+	Announcements []*teamvite.Announcement
+	Divisions     []*teamvite.Division
+	Invitations   []*teamvite.Invitation
+	JoinRequests  []*teamvite.JoinRequest
+	IsManager     bool
+	// The user is logged in but not on the team
+	CanJoin bool
+
+	// Ownership transfer waiting on a response, only set for the new owner
+	// (on the team page) or managers (on the edit page)
+	Transfer *teamvite.Transfer
+	// The user can offer ownership to another player
+	CanTransfer bool
+
+	// Seasons the team has played (on the team page) or all seasons (on the
+	// edit page, to start a new one)
+	Seasons []*teamvite.Season
+	// Season of the roster being shown, nil for the current roster
+	Season *teamvite.Season
+	// The user hasn't confirmed they are returning for the current season
+	SeasonPending bool
+}
+
+type teamJSON struct {
+	Team    *teamvite.Team     `json:"team"`
+	Players []*teamvite.Player `json:"players"`
+	Games   []*teamvite.Game   `json:"games"`
 }
 
 type teamListParams struct {
-	Teams []*teamvite.Team
-	Q     string // query
+	Teams    []*teamvite.Team
+	Q        string // query
+	LoggedIn bool
 }
 
 func (s *Server) teamShow() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		team := teamvite.TeamFromContext(ctx)
-		players, _, err := s.PlayerService.FindPlayers(r.Context(), teamvite.PlayerFilter{TeamID: &team.ID})
+		seasons, err := s.TeamService.FindTeamSeasons(ctx, team.ID)
 		if err != nil {
 			s.Error(w, r, err)
 			return
 		}
-		games, _, err := s.GameService.FindGames(
-			r.Context(),
-			teamvite.GameFilter{TeamID: team.ID, Time: time.Now().Unix()})
+
+		// past seasons show that season's roster
+		filter := teamvite.PlayerFilter{TeamID: &team.ID}
+		var season *teamvite.Season
+		if id, err := strconv.ParseUint(r.URL.Query().Get("season_id"), 10, 64); err == nil && id != team.SeasonID {
+			for _, ts := range seasons {
+				if uint64(ts.ID) == id {
+					season = ts
+				}
+			}
+			if season == nil {
+				s.Error(w, r, teamvite.Errorf(teamvite.ENOTFOUND, "%s has no roster for season %d", team.Name, id))
+				return
+			}
+			filter.SeasonID = &id
+		}
+
+		players, _, err := s.PlayerService.FindPlayers(r.Context(), filter)
 		if err != nil {
 			s.Error(w, r, err)
 			return
 		}
+		// a past season shows all of that season's games, otherwise upcoming
+		// games of open seasons
+		gameFilter := teamvite.GameFilter{TeamID: team.ID, OpenSeasons: true, Time: time.Now().Unix()}
+		if season != nil {
+			gameFilter = teamvite.GameFilter{TeamID: team.ID, SeasonID: uint64(season.ID)}
+		}
+		games, _, err := s.GameService.FindGames(r.Context(), gameFilter)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+
+		if r.Header.Get("Content-type") == JSON {
+			w.Header().Set("Content-Type", JSON)
+			json.NewEncoder(w).Encode(teamJSON{Team: team, Players: players, Games: games})
+			return
+		}
+
+		var announcements []*teamvite.Announcement
+		if team.RosterVisible() {
+			announcements, err = s.AnnouncementService.FindAnnouncements(r.Context(), team.ID)
+			if err != nil {
+				s.Error(w, r, err)
+				return
+			}
+		}
 
 		templateParams := teamShowParams{
-			Players:   players,
-			Team:      team,
-			Games:     games,
-			IsManager: s.isManager(r.Context(), team),
+			Players:       players,
+			Team:          team,
+			Games:         games,
+			Announcements: announcements,
+			IsManager:     s.isManager(r.Context(), team),
+			Seasons:       seasons,
+			Season:        season,
+		}
+
+		if userID := teamvite.UserIDFromContext(ctx); userID != 0 && season == nil {
+			templateParams.CanJoin = !team.IsMember
+			for _, p := range players {
+				if p.ID == userID {
+					templateParams.SeasonPending = p.SeasonStatus == teamvite.MembershipPending
+				}
+			}
+
+			transfers, err := s.TeamService.FindTransfers(ctx, teamvite.TransferFilter{TeamID: team.ID, ToPlayerID: userID})
+			if err != nil {
+				s.Error(w, r, err)
+				return
+			}
+			if len(transfers) > 0 {
+				templateParams.Transfer = transfers[0]
+			}
 		}
 
 		s.RenderTemplate(w, r, teamvite.TemplateFromContext(ctx), templateParams)
@@ -75,168 +166,323 @@ func (s *Server) teamEdit() http.Handler {
 			return
 		}
 
-		templateParams := teamShowParams{
-			Team:    team,
-			Players: players,
-			Games:   games,
+		announcements, err := s.AnnouncementService.FindAnnouncements(r.Context(), team.ID)
+		if err != nil {
+			s.Error(w, r, err)
+			return
 		}
-		s.RenderTemplate(w, r, teamvite.TemplateFromContext(ctx), templateParams)
-	})
 
-}
+		// the team's league's divisions, it can be edited from another league's host
+		divisions, _, err := s.DivisionService.FindDivisions(r.Context(), teamvite.DivisionFilter{OrganizationID: team.OrganizationID})
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
 
-func (s *Server) teamList() http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
+		transfers, err := s.TeamService.FindTransfers(ctx, teamvite.TransferFilter{TeamID: team.ID})
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
 
-		// TODO: Add filtering by division name (for creating games)
-		nameQuery := ""
-		q := r.URL.Query().Get("name")
-		nameQuery = "%" + q + "%"
+		invitations, err := s.InvitationService.FindInvitations(ctx, teamvite.InvitationFilter{
+			TeamID:   team.ID,
+			Statuses: []string{teamvite.InvitationPending, teamvite.InvitationDeclined},
+		})
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
 
-		teams, _, err := s.TeamService.FindTeams(r.Context(), teamvite.TeamFilter{Name: &nameQuery})
+		// only open seasons can be started
+		closed := false
+		seasons, _, err := s.SeasonService.FindSeasons(ctx, teamvite.SeasonFilter{Closed: &closed})
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+
+		pending := teamvite.JoinRequestPending
+		joinRequests, err := s.JoinRequestService.FindJoinRequests(ctx, teamvite.JoinRequestFilter{
+			TeamID: team.ID, Status: &pending})
 		if err != nil {
 			s.Error(w, r, err)
 			return
 		}
 
+		userID := teamvite.UserIDFromContext(ctx)
+		templateParams := teamShowParams{
+			Team:          team,
+			Players:       players,
+			Games:         games,
+			Announcements: announcements,
+			Divisions:     divisions,
+			Invitations:   invitations,
+			JoinRequests:  joinRequests,
+			Seasons:       seasons,
+			IsManager:     true,
+			CanTransfer:   team.OwnerID == 0 || team.OwnerID == userID,
+		}
+		if len(transfers) > 0 {
+			templateParams.Transfer = transfers[0]
+		}
+		s.RenderTemplate(w, r, teamvite.TemplateFromContext(ctx), templateParams)
+	})
+
+}
+
+// Form posts redirect back to the edit page, JSON requests get the updated
+// team.
+//
+// curl -i -X PATCH --silent \
+// http://teamvitedev.com:8080/team/1 \
+// -H 'Content-Type: application/json' \
+// --data '{"name":"New Name"}'
+func (s *Server) teamUpdate() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		team := teamvite.TeamFromContext(r.Context())
+
+		var upd teamvite.TeamUpdate
 		switch r.Header.Get("Content-type") {
 		case JSON:
+			if err := json.NewDecoder(r.Body).Decode(&upd); err != nil {
+				s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid JSON body."))
+				return
+			}
+			t, err := s.TeamService.UpdateTeam(r.Context(), team.ID, upd)
+			if err != nil {
+				s.Error(w, r, err)
+				return
+			}
 			w.Header().Set("Content-Type", JSON)
-			json.NewEncoder(w).Encode(teams)
+			json.NewEncoder(w).Encode(t)
 		default:
-			templateParams := teamListParams{
-				Q:     q,
-				Teams: teams,
+			if err := r.ParseForm(); err != nil {
+				s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+				return
 			}
-			s.RenderTemplate(w, r, teamvite.TemplateFromContext(ctx), templateParams)
+			name := r.PostForm.Get("name")
+			upd.Name = &name
+			if d, err := strconv.ParseUint(r.PostForm.Get("division_id"), 10, 64); err == nil {
+				upd.DivisionID = &d
+			}
+			if privacy := r.PostForm.Get("privacy"); privacy != "" {
+				upd.Privacy = &privacy
+			}
+			_, err := s.TeamService.UpdateTeam(r.Context(), team.ID, upd)
+			s.redirectWithResult(w, r, err, UrlFor(team, "edit"), "Team updated")
 		}
 	})
 }
 
-func (s *Server) teamAddPlayer() http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
-		team := teamvite.TeamFromContext(ctx)
+// redirectWithResult redirects to url with msg as the flash. Invalid requests
+// flash the error message instead and other errors render the error page.
+func (s *Server) redirectWithResult(w http.ResponseWriter, r *http.Request, err error, url, msg string) {
+	switch teamvite.ErrorCode(err) {
+	case "":
+		SetFlash(w, msg)
+	case teamvite.EINVALID, teamvite.ECONFLICT:
+		SetFlash(w, teamvite.ErrorMessage(err))
+	default:
+		s.Error(w, r, err)
+		return
+	}
+	http.Redirect(w, r, url, http.StatusFound)
+}
 
+func (s *Server) teamSetManager() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		team := teamvite.TeamFromContext(r.Context())
 		if !s.isManager(r.Context(), team) {
 			http.Redirect(w, r, "/user/login", http.StatusSeeOther)
 			return
 		}
-
 		if err := r.ParseForm(); err != nil {
-			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "invalid form %v", err))
 			return
 		}
+		playerID, _ := strconv.ParseUint(r.PostForm.Get("player_id"), 10, 64)
+		isMgr := r.PostForm.Get("is_manager") == "true"
 
-		email := r.PostForm.Get("email")
-		name := r.PostForm.Get("name")
+		err := s.TeamService.SetManager(r.Context(), team, playerID, isMgr)
+		msg := "Player is no longer a manager"
+		if isMgr {
+			msg = "Player is now a manager"
+		}
+		s.redirectWithResult(w, r, err, UrlFor(team, "edit"), msg)
+	})
+}
 
-		if email == "" {
-			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Email is required."))
+func (s *Server) teamUpdateRosterPlayer() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		team := teamvite.TeamFromContext(r.Context())
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "invalid form %v", err))
 			return
 		}
-		p, n, err := s.PlayerService.FindPlayers(r.Context(), teamvite.PlayerFilter{Email: email, Limit: 1})
-		if err != nil || n > 1 {
-			s.Error(w, r, err)
+		playerID, _ := strconv.ParseUint(r.PostForm.Get("player_id"), 10, 64)
+
+		jersey := r.PostForm.Get("jersey")
+		positions := r.PostForm.Get("positions")
+		status := r.PostForm.Get("roster_status")
+		upd := teamvite.RosterUpdate{Jersey: &jersey, Positions: &positions, Status: &status}
+
+		err := s.TeamService.UpdateRosterPlayer(r.Context(), team, playerID, upd)
+		s.redirectWithResult(w, r, err, UrlFor(team, "edit"), "Roster updated")
+	})
+}
+
+func (s *Server) teamTransfer() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		team := teamvite.TeamFromContext(r.Context())
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "invalid form %v", err))
 			return
 		}
-		var player teamvite.Player
+		playerID, _ := strconv.ParseUint(r.PostForm.Get("player_id"), 10, 64)
 
-		if n == 0 {
-			player = teamvite.Player{Name: name, Email: email}
-			err = s.PlayerService.CreatePlayer(r.Context(), &player)
-			if err != nil {
-				s.Error(w, r, err)
-				return
-			}
-		} else {
-			player = *p[0]
-		}
+		err := s.TeamService.TransferOwnership(r.Context(), team, playerID)
+		s.redirectWithResult(w, r, err, UrlFor(team, "edit"), "Ownership transfer sent, waiting for the new owner to accept")
+	})
+}
 
-		err = s.TeamService.AddPlayer(teamvite.NewContextWithUser(r.Context(), &player), team)
-		if err != nil {
-			s.Error(w, r, err)
-			return
+func (s *Server) teamRespondToTransfer(accept bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		team := teamvite.TeamFromContext(r.Context())
+		err := s.TeamService.RespondToTransfer(r.Context(), team, accept)
+		msg := "Transfer declined"
+		if accept {
+			msg = fmt.Sprintf("You are now the owner of %s", team.Name)
 		}
-		http.Redirect(w, r, UrlFor(team, "edit"), http.StatusFound)
+		s.redirectWithResult(w, r, err, UrlFor(team, "show"), msg)
 	})
 }
 
-func (s *Server) teamRemovePlayer() http.Handler {
+func (s *Server) teamStartSeason() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		team := teamvite.TeamFromContext(ctx)
-		if !s.isManager(r.Context(), team) {
-			http.Redirect(w, r, "/user/login", http.StatusSeeOther)
-			return
-		}
-
 		if err := r.ParseForm(); err != nil {
 			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "invalid form %v", err))
 			return
 		}
-		playerID, _ := strconv.Atoi(r.PostForm.Get("player_id"))
-		player, err := s.PlayerService.FindPlayerByID(r.Context(), uint64(playerID))
-		if err != nil {
-			s.Error(w, r, err)
-			return
-		}
+		seasonID, _ := strconv.ParseUint(r.PostForm.Get("season_id"), 10, 64)
 
-		if err := s.TeamService.RemovePlayer(teamvite.NewContextWithUser(r.Context(), player), team); err != nil {
-			s.Error(w, r, err)
-			return
+		err := s.TeamService.StartSeason(ctx, team, seasonID)
+		if err == nil {
+			if err := s.askReturning(ctx, team); err != nil {
+				log.Printf("[ERROR] asking players of team %d to confirm season: %s", team.ID, err)
+			}
 		}
-		http.Redirect(w, r, UrlFor(team, "edit"), http.StatusFound)
+		s.redirectWithResult(w, r, err, UrlFor(team, "edit"),
+			fmt.Sprintf("Started %s, players have been asked to confirm they are returning", team.SeasonName))
 	})
 }
 
-type TeamCalendarParams struct {
-	Team       teamvite.Team
-	Games      []CalendarGame
-	CreateTime time.Time
+func (s *Server) teamRespondToSeason(returning bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		team := teamvite.TeamFromContext(r.Context())
+		err := s.TeamService.RespondToSeason(r.Context(), team, returning)
+		msg, url := fmt.Sprintf("See you in %s!", team.SeasonName), UrlFor(team, "show")
+		if !returning {
+			msg, url = fmt.Sprintf("You've left %s", team.Name), "/"
+		}
+		s.redirectWithResult(w, r, err, url, msg)
+	})
 }
 
-type CalendarGame struct {
-	Url         string
-	Description string
-	Start       *time.Time
-	End         *time.Time
+var returningMailTemplate = htmltemplate.Must(htmltemplate.New("returning").Parse(`
+{{ .Team.Name }} is starting {{ .Team.SeasonName }}. Are you playing this season?<br>
+<a href="{{ .TeamURL }}">Let your team know</a><br>
+
+Thank you for using {{ .League }}!
+`))
+
+// askReturning emails players carried forward into the team's new season
+// asking them to confirm on the team page.
+func (s *Server) askReturning(ctx context.Context, team *teamvite.Team) error {
+	players, _, err := s.PlayerService.FindPlayers(ctx, teamvite.PlayerFilter{TeamID: &team.ID})
+	if err != nil {
+		return err
+	}
+	var body bytes.Buffer
+	err = returningMailTemplate.Execute(&body, map[string]interface{}{
+		"League":  teamvite.OrganizationFromContext(ctx).Name,
+		"Team":    team,
+		"TeamURL": fmt.Sprintf("https://%s%s", serverName(ctx), UrlFor(team, "show")),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range players {
+		if p.SeasonStatus != teamvite.MembershipPending || p.Email == "" {
+			continue
+		}
+		err := s.MailService.SendMail(ctx, &teamvite.Mail{
+			To:      []string{p.Email},
+			Subject: fmt.Sprintf("Are you playing %s with %s?", team.SeasonName, team.Name),
+			Body:    body.String(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (s *Server) teamCalendar() http.Handler {
+func (s *Server) teamList() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
-		team := teamvite.TeamFromContext(ctx)
-		games, _, err := s.GameService.FindGames(r.Context(), teamvite.GameFilter{TeamID: team.ID})
+
+		// TODO: Add filtering by division name (for creating games)
+		nameQuery := ""
+		q := r.URL.Query().Get("name")
+		nameQuery = "%" + q + "%"
+
+		teams, _, err := s.TeamService.FindTeams(r.Context(), teamvite.TeamFilter{Name: &nameQuery})
 		if err != nil {
 			s.Error(w, r, err)
 			return
 		}
 
-		cg := []CalendarGame{}
+		switch r.Header.Get("Content-type") {
+		case JSON:
+			w.Header().Set("Content-Type", JSON)
+			json.NewEncoder(w).Encode(teams)
+		default:
+			templateParams := teamListParams{
+				Q:        q,
+				Teams:    teams,
+				LoggedIn: teamvite.UserIDFromContext(ctx) != 0,
+			}
+			s.RenderTemplate(w, r, teamvite.TemplateFromContext(ctx), templateParams)
+		}
+	})
+}
 
-		for _, g := range games {
-			e := g.Time.Add(time.Minute * teamvite.GameLength)
-			cg = append(cg, CalendarGame{
-				Url:         fmt.Sprintf("https://www.teamvite.com%s", UrlFor(g, "show")),
-				Description: g.Description,
-				Start:       g.Time,
-				End:         &e,
-			})
+func (s *Server) teamRemovePlayer() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		team := teamvite.TeamFromContext(ctx)
+		if !s.isManager(r.Context(), team) {
+			http.Redirect(w, r, "/user/login", http.StatusSeeOther)
+			return
 		}
 
-		params := TeamCalendarParams{
-			Team:       *team,
-			Games:      cg,
-			CreateTime: time.Now(),
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "invalid form %v", err))
+			return
+		}
+		playerID, _ := strconv.Atoi(r.PostForm.Get("player_id"))
+		player, err := s.PlayerService.FindPlayerByID(r.Context(), uint64(playerID))
+		if err != nil {
+			s.Error(w, r, err)
+			return
 		}
 
-		filename := "views/team/calendar.ics.tmpl"
-		// parse as text/template to avoid html escaping
-		t := template.Must(template.ParseFS(views, filename))
-		w.Header().Set("Content-Type", "text/calendar;charset=utf-8")
-		t.ExecuteTemplate(w, "calendar.ics.tmpl", params)
+		err = s.TeamService.RemovePlayer(teamvite.NewContextWithUser(r.Context(), player), team)
+		s.redirectWithResult(w, r, err, UrlFor(team, "edit"), fmt.Sprintf("Removed %s", player.Name))
 	})
 }
 
@@ -11,18 +11,22 @@ import (
 )
 
 var fMap = template.FuncMap{
-	"gravatarKey":  teamvite.GravatarKey,
-	"urlFor":       UrlFor,
-	"playerEmails": playerEmails,
-	"CalendarUrl":  CalendarUrl,
-	"Telify":       teamvite.Telify,
-	"ReminderID":   teamvite.ReminderID,
+	"gravatarKey":     teamvite.GravatarKey,
+	"urlFor":          UrlFor,
+	"playerEmails":    playerEmails,
+	"CalendarUrl":     CalendarUrl,
+	"Telify":          teamvite.Telify,
+	"ReminderID":      teamvite.ReminderID,
+	"DefaultStatusID": teamvite.DefaultStatusID,
+	"PositionsID":     teamvite.PositionsID,
+	"JoinURL":         JoinURL,
 }
 
 type LayoutData struct {
-	Message string
-	User    *teamvite.Player
-	Page    interface{} // page specific parameters
+	Message      string
+	User         *teamvite.Player
+	Organization *teamvite.Organization // league the site is branded for
+	Page         interface{}            // page specific parameters
 }
 
 //go:embed views
@@ -47,9 +51,10 @@ func (s *Server) RenderTemplate(w http.ResponseWriter, r *http.Request, template
 	}
 
 	params := LayoutData{
-		Message: msg,
-		User:    user,
-		Page:    templateParams,
+		Message:      msg,
+		User:         user,
+		Organization: teamvite.OrganizationFromContext(r.Context()),
+		Page:         templateParams,
 	}
 
 	log.Println("Executing layout.tmpl")
@@ -69,6 +74,8 @@ func defaultTemplates() *template.Template {
 	return template.Must(tmpl.ParseFS(views, "views/partials/*.tmpl"))
 }
 
+// Deprecated: only public teams can be subscribed to by their guessable
+// url, use a CalendarFeed instead.
 func CalendarUrl(t teamvite.Item) template.URL {
 	return template.URL(fmt.Sprintf(
 		"webcal://%s/team/%d/calendar.ics",
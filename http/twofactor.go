@@ -0,0 +1,320 @@
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	teamvite "github.com/benprew/teamvite"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// cookie holding the login token of a player who still needs to enter their
+// two-factor code
+const TWO_FACTOR_KEY = "teamvite-2fa"
+
+// codes are only 6 digits, so limit guesses per player
+var twoFactorLimiter = newRateLimiter(10, time.Hour)
+
+// pages players can reach before entering a code the policy requires
+var twoFactorExempt = map[string]bool{
+	"/user/two_factor":        true,
+	"/user/verify_two_factor": true,
+	"/user/logout":            true,
+}
+
+type twoFactorParams struct {
+	Enabled       bool
+	Secret        string
+	QRCode        template.URL
+	RecoveryCodes []string
+}
+
+// logIn starts a session for the player and sends them to url. Players with
+// two-factor on are sent to enter a code first.
+func (s *Server) logIn(w http.ResponseWriter, r *http.Request, player *teamvite.Player, url, msg string) {
+	enabled, err := s.twoFactorEnabled(r.Context(), player.ID)
+	if err != nil {
+		s.Error(w, r, err)
+		return
+	}
+	if enabled {
+		token, err := s.SessionService.NewLoginToken(player.ID, teamvite.TwoFactorLoginLength)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		s.setPendingLogin(w, token)
+		http.Redirect(w, r, "/user/verify_two_factor", http.StatusFound)
+		return
+	}
+
+	session, err := s.SessionService.New(player.ID, RequestIP(r), time.Hour*24*30)
+	if err != nil {
+		s.Error(w, r, err)
+		return
+	}
+	s.setSession(w, session)
+	log.Printf("created session [player_id=%d]\n", player.ID)
+	if msg != "" {
+		SetFlash(w, msg)
+	}
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+func (s *Server) twoFactorEnabled(ctx context.Context, playerID uint64) (bool, error) {
+	tf, err := s.TwoFactorService.FindTwoFactor(ctx, playerID)
+	if teamvite.ErrorCode(err) == teamvite.ENOTFOUND {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return tf.Enabled, nil
+}
+
+// twoFactorRequired returns where to send a player whose session doesn't
+// meet the RequireTwoFactor policy, or "" if it does.
+func (s *Server) twoFactorRequired(r *http.Request, playerID uint64) (string, error) {
+	if twoFactorExempt[r.URL.Path] {
+		return "", nil
+	}
+	privileged, err := s.TwoFactorService.Privileged(r.Context(), playerID)
+	if err != nil || !privileged {
+		return "", err
+	}
+	enabled, err := s.twoFactorEnabled(r.Context(), playerID)
+	if err != nil {
+		return "", err
+	}
+	if enabled {
+		return "/user/verify_two_factor", nil
+	}
+	return "/user/two_factor", nil
+}
+
+// Shows whether two-factor is on, or a QR code for the player's authenticator
+// app to set it up.
+func (s *Server) userTwoFactor() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := s.GetUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/user/login", http.StatusFound)
+			return
+		}
+
+		enabled, err := s.twoFactorEnabled(r.Context(), user.ID)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		if enabled {
+			s.RenderTemplate(w, r, "views/user/two_factor.tmpl", twoFactorParams{Enabled: true})
+			return
+		}
+
+		tf, err := s.TwoFactorService.BeginTwoFactor(r.Context(), user.ID)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		org := teamvite.OrganizationFromContext(r.Context())
+		// the QR code is made here so the secret isn't sent anywhere else
+		png, err := qrcode.Encode(teamvite.TOTPURL(tf.Secret, org.Name, user.Email), qrcode.Medium, 256)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		s.RenderTemplate(w, r, "views/user/two_factor.tmpl", twoFactorParams{
+			Secret: tf.Secret,
+			QRCode: template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(png)),
+		})
+	})
+}
+
+// Turns two-factor on once the player enters a code from their app, and shows
+// their recovery codes. They aren't shown again.
+func (s *Server) userTwoFactorPost() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := s.GetUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/user/login", http.StatusFound)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			return
+		}
+
+		codes, err := s.TwoFactorService.EnableTwoFactor(r.Context(), user.ID, r.PostForm.Get("code"))
+		if err != nil {
+			s.redirectWithResult(w, r, err, "/user/two_factor", "")
+			return
+		}
+		// entering the code counts for this session too
+		if session := teamvite.SessionFromContext(r.Context()); session != nil {
+			if err := s.SessionService.MarkTwoFactor(session.ID); err != nil {
+				s.Error(w, r, err)
+				return
+			}
+		}
+		log.Printf("enabled two-factor [player_id=%d]\n", user.ID)
+		s.RenderTemplate(w, r, "views/user/two_factor.tmpl", twoFactorParams{Enabled: true, RecoveryCodes: codes})
+	})
+}
+
+func (s *Server) userDisableTwoFactor() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := s.GetUser(r)
+		if user == nil {
+			http.Redirect(w, r, "/user/login", http.StatusFound)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			return
+		}
+		if teamvite.CONFIG.RequireTwoFactor {
+			privileged, err := s.TwoFactorService.Privileged(r.Context(), user.ID)
+			if err != nil {
+				s.Error(w, r, err)
+				return
+			} else if privileged {
+				SetFlash(w, "Team managers and league admins must use two-factor authentication.")
+				http.Redirect(w, r, "/user/two_factor", http.StatusFound)
+				return
+			}
+		}
+		if !twoFactorLimiter.Allow(fmt.Sprint(user.ID)) {
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		// a code is needed so a session left logged in can't turn it off
+		if err := s.TwoFactorService.CheckTwoFactor(r.Context(), user.ID, r.PostForm.Get("code")); err != nil {
+			s.twoFactorCodeError(w, r, err, "/user/two_factor")
+			return
+		}
+		if err := s.TwoFactorService.DisableTwoFactor(r.Context(), user.ID); err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		log.Printf("disabled two-factor [player_id=%d]\n", user.ID)
+		SetFlash(w, "Two-factor authentication is off.")
+		http.Redirect(w, r, "/user/two_factor", http.StatusFound)
+	})
+}
+
+func (s *Server) userVerifyTwoFactor() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(SidsFromCookie(r, TWO_FACTOR_KEY)) == 0 && s.GetUser(r) == nil {
+			http.Redirect(w, r, "/user/login", http.StatusFound)
+			return
+		}
+		s.RenderTemplate(w, r, "views/user/verify_two_factor.tmpl", nil)
+	})
+}
+
+// Checks the code of a player logging in, or of a logged in player whose
+// session needs one because of the RequireTwoFactor policy.
+func (s *Server) userVerifyTwoFactorPost() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			return
+		}
+		code := strings.TrimSpace(r.PostForm.Get("code"))
+
+		pending := SidsFromCookie(r, TWO_FACTOR_KEY)
+		if len(pending) == 0 {
+			s.verifySessionTwoFactor(w, r, code)
+			return
+		}
+		token, err := s.SessionService.FindLoginToken(pending[0])
+		if teamvite.ErrorCode(err) == teamvite.ENOTFOUND {
+			s.setPendingLogin(w, teamvite.Session{})
+			SetFlash(w, teamvite.ErrorMessage(err))
+			http.Redirect(w, r, "/user/login", http.StatusFound)
+			return
+		} else if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		if !twoFactorLimiter.Allow(fmt.Sprint(token.PlayerID)) {
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		if err := s.TwoFactorService.CheckTwoFactor(r.Context(), token.PlayerID, code); err != nil {
+			s.twoFactorCodeError(w, r, err, "/user/verify_two_factor")
+			return
+		}
+
+		session, err := s.SessionService.RedeemLoginToken(token.ID, RequestIP(r), time.Hour*24*30)
+		if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		if err := s.SessionService.MarkTwoFactor(session.ID); err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		s.setPendingLogin(w, teamvite.Session{})
+		s.setSession(w, session)
+		log.Printf("created two-factor session [player_id=%d]\n", session.PlayerID)
+		http.Redirect(w, r, UrlFor(&teamvite.Player{ID: session.PlayerID}, "show"), http.StatusFound)
+	})
+}
+
+func (s *Server) verifySessionTwoFactor(w http.ResponseWriter, r *http.Request, code string) {
+	user := s.GetUser(r)
+	session := teamvite.SessionFromContext(r.Context())
+	if user == nil || session == nil {
+		http.Redirect(w, r, "/user/login", http.StatusFound)
+		return
+	}
+	if !twoFactorLimiter.Allow(fmt.Sprint(user.ID)) {
+		http.Error(w, "Too many requests", http.StatusTooManyRequests)
+		return
+	}
+	if err := s.TwoFactorService.CheckTwoFactor(r.Context(), user.ID, code); err != nil {
+		s.twoFactorCodeError(w, r, err, "/user/verify_two_factor")
+		return
+	}
+	if err := s.SessionService.MarkTwoFactor(session.ID); err != nil {
+		s.Error(w, r, err)
+		return
+	}
+	http.Redirect(w, r, UrlFor(user, "show"), http.StatusFound)
+}
+
+// wrong codes are flashed so the player can try again
+func (s *Server) twoFactorCodeError(w http.ResponseWriter, r *http.Request, err error, url string) {
+	if teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+		s.Error(w, r, err)
+		return
+	}
+	SetFlash(w, teamvite.ErrorMessage(err))
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+// setPendingLogin remembers the login token of a player who needs to enter
+// their code. An empty token clears it.
+func (s *Server) setPendingLogin(w http.ResponseWriter, token teamvite.Session) {
+	cookie := &http.Cookie{
+		Name:     TWO_FACTOR_KEY,
+		Value:    token.ID,
+		Domain:   s.Domain,
+		Expires:  token.ExpiresOn,
+		Secure:   false, // teamvite serves http behind nginx proxy
+		HttpOnly: true,
+		Path:     "/",
+		SameSite: http.SameSiteStrictMode,
+	}
+	if token.ID == "" {
+		cookie.MaxAge = -1
+	}
+	http.SetCookie(w, cookie)
+}
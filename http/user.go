@@ -1,9 +1,14 @@
 package http
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"html/template"
 	"log"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	teamvite "github.com/benprew/teamvite"
@@ -11,6 +16,12 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// How long an emailed login link works for
+const LoginLinkLength = time.Minute * 15
+
+// each request sends mail, so limit them per IP and per email
+var loginLinkLimiter = newRateLimiter(5, time.Hour)
+
 func (s *Server) GetUser(req *http.Request) (usr *teamvite.Player) {
 	return teamvite.UserFromContext(req.Context())
 }
@@ -60,7 +71,7 @@ func (s *Server) userLoginPost() http.Handler {
 		player := players[0]
 		hash := []byte(player.Password)
 		if player.Password == "" {
-			msg := "user must reset password"
+			msg := "Your account doesn't have a password yet, email yourself a login link or sign up with this email to set one"
 			SetFlash(w, msg)
 			log.Println(msg)
 			http.Redirect(w, r, "/user/login", http.StatusFound)
@@ -70,24 +81,129 @@ func (s *Server) userLoginPost() http.Handler {
 		// Comparing the password with the hash
 		err = bcrypt.CompareHashAndPassword(hash, password)
 		if err != nil {
-			msg := "incorrect password"
+			msg := "Incorrect password, use \"Forgot your password?\" to reset it"
 			SetFlash(w, msg)
-			log.Println(msg, err, string(password[:]), string(hash[:]))
+			log.Println(msg, err)
 			http.Redirect(w, r, "/user/login", http.StatusFound)
 			return
 		}
 		log.Println("DEBUG: logging in as user:", player)
-		userID := player.ID
-		ip := RequestIP(r)
-		log.Println("sessionservice", s.SessionService)
-		session, err := s.SessionService.New(userID, ip, time.Hour*24*30)
+		s.logIn(w, r, player, UrlFor(player, "show"), "")
+	})
+}
+
+// Emails a single-use login link. The response is the same whether or not
+// there is an account, so it can't be used to find out who has one.
+func (s *Server) userLoginLinkPost() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			return
+		}
+		email := strings.ToLower(strings.TrimSpace(r.PostForm.Get("email")))
+		if !loginLinkLimiter.Allow("ip "+RequestIP(r).String()) || !loginLinkLimiter.Allow("email "+email) {
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+
+		players, _, err := s.PlayerService.FindPlayers(r.Context(), teamvite.PlayerFilter{Email: email, Limit: 1})
 		if err != nil {
 			s.Error(w, r, err)
 			return
 		}
+		if len(players) == 0 {
+			log.Printf("[INFO] login link for unknown email: %s", email)
+		} else {
+			token, err := s.SessionService.NewLoginToken(players[0].ID, LoginLinkLength)
+			if err != nil {
+				s.Error(w, r, err)
+				return
+			}
+			if err := s.sendLoginLink(r.Context(), players[0], token); err != nil {
+				s.Error(w, r, err)
+				return
+			}
+		}
+		SetFlash(w, fmt.Sprintf("If there is an account for %s, we emailed it a login link.", email))
+		http.Redirect(w, r, "/user/login", http.StatusFound)
+	})
+}
+
+// The emailed link shows a button rather than logging in, so mail scanners
+// that follow links don't use it up.
+func (s *Server) userLoginLink() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.RenderTemplate(w, r, "views/user/login_link.tmpl", map[string]string{"Token": r.URL.Query().Get("token")})
+	})
+}
+
+func (s *Server) userLoginLinkRedeem() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			s.Error(w, r, teamvite.Errorf(teamvite.EINVALID, "Invalid request."))
+			return
+		}
+		token := r.PostForm.Get("token")
+		pending, err := s.SessionService.FindLoginToken(token)
+		if teamvite.ErrorCode(err) == teamvite.ENOTFOUND {
+			SetFlash(w, "That login link isn't valid, it may have expired or already been used.")
+			http.Redirect(w, r, "/user/login", http.StatusFound)
+			return
+		} else if err != nil {
+			s.Error(w, r, err)
+			return
+		}
+		// the link stands in for the password, a code is still needed
+		if enabled, err := s.twoFactorEnabled(r.Context(), pending.PlayerID); err != nil {
+			s.Error(w, r, err)
+			return
+		} else if enabled {
+			s.setPendingLogin(w, pending)
+			http.Redirect(w, r, "/user/verify_two_factor", http.StatusFound)
+			return
+		}
+
+		session, err := s.SessionService.RedeemLoginToken(token, RequestIP(r), time.Hour*24*30)
+		if teamvite.ErrorCode(err) == teamvite.ENOTFOUND {
+			SetFlash(w, teamvite.ErrorMessage(err))
+			http.Redirect(w, r, "/user/login", http.StatusFound)
+			return
+		} else if err != nil {
+			s.Error(w, r, err)
+			return
+		}
 
 		s.setSession(w, session)
-		log.Printf("created session [player_id=%d]\n", userID)
-		http.Redirect(w, r, UrlFor(player, "show"), http.StatusFound)
+		log.Printf("created session from login link [player_id=%d]\n", session.PlayerID)
+		http.Redirect(w, r, UrlFor(&teamvite.Player{ID: session.PlayerID}, "show"), http.StatusFound)
+	})
+}
+
+var loginLinkMailTemplate = template.Must(template.New("login_link").Parse(`
+Dear {{ .Player.Name }},<br>
+Follow this link to log in:
+<a href="{{ .LoginURL }}">{{ .LoginURL }}</a><br>
+The link works once, for the next 15 minutes. If you didn't ask to log in you can ignore this email.<br>
+
+Thank you for using {{ .League }}!
+`))
+
+func (s *Server) sendLoginLink(ctx context.Context, player *teamvite.Player, token teamvite.Session) error {
+	league := teamvite.OrganizationFromContext(ctx).Name
+	var body bytes.Buffer
+	err := loginLinkMailTemplate.Execute(&body, map[string]interface{}{
+		"League": league,
+		"Player": player,
+		"LoginURL": fmt.Sprintf("https://%s/user/login_link?token=%s",
+			serverName(ctx), url.QueryEscape(token.ID)),
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.MailService.SendMail(ctx, &teamvite.Mail{
+		To:      []string{player.Email},
+		Subject: fmt.Sprintf("Your %s login link", league),
+		Body:    body.String(),
 	})
 }
@@ -0,0 +1,72 @@
+package teamvite
+
+import (
+	"context"
+	"time"
+)
+
+// An invitation for someone to join a team. The roster isn't changed until
+// the invitation is accepted.
+type Invitation struct {
+	ID            uint64    `json:"id"`
+	TeamID        uint64    `json:"team_id"`
+	TeamName      string    `json:"team_name"`
+	Email         string    `json:"email"`
+	Name          string    `json:"name"`
+	Phone         int       `json:"-"`
+	IsManager     bool      `json:"is_manager"` // made a manager on acceptance
+	Token         string    `json:"-"`
+	InvitedByID   uint64    `json:"invited_by_id"`
+	InvitedByName string    `json:"invited_by_name"`
+	Status        string    `json:"status"`
+	CreatedOn     time.Time `json:"created_on"`
+	ExpiresOn     time.Time `json:"expires_on"`
+}
+
+// Invitation statuses
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationDeclined = "declined"
+	InvitationRevoked  = "revoked"
+)
+
+// How long an invitation can be accepted for after it is sent
+const InvitationLength = time.Hour * 24 * 14
+
+func (i *Invitation) Expired() bool {
+	return time.Now().After(i.ExpiresOn)
+}
+
+type InvitationService interface {
+	// Creates a pending invitation from the user in the context with a new
+	// token. Returns ECONFLICT if the email is already on the team or has a
+	// pending invitation, or EUNAUTHORIZED unless the user manages the team.
+	CreateInvitation(ctx context.Context, inv *Invitation) error
+
+	// Retrieves a pending, unexpired invitation by its token. Returns
+	// ENOTFOUND otherwise.
+	FindInvitationByToken(ctx context.Context, token string) (*Invitation, error)
+
+	FindInvitations(ctx context.Context, filter InvitationFilter) ([]*Invitation, error)
+
+	// Adds the invited player to the team, creating the player if needed.
+	AcceptInvitation(ctx context.Context, token string) (*Invitation, error)
+
+	DeclineInvitation(ctx context.Context, token string) (*Invitation, error)
+
+	// Cancels a pending invitation of the team. Only managers can revoke and
+	// renew invitations.
+	RevokeInvitation(ctx context.Context, teamID, id uint64) error
+
+	// Gives a pending invitation of the team a new token and expiration so
+	// it can be sent again.
+	RenewInvitation(ctx context.Context, teamID, id uint64) (*Invitation, error)
+}
+
+type InvitationFilter struct {
+	ID       uint64   `json:"id"`
+	TeamID   uint64   `json:"team_id"`
+	Token    string   `json:"-"`
+	Statuses []string `json:"statuses"`
+}
@@ -0,0 +1,52 @@
+package teamvite
+
+import (
+	"context"
+	"time"
+)
+
+// A request from a player to join a team. Managers approve or reject it.
+type JoinRequest struct {
+	ID          uint64    `json:"id"`
+	TeamID      uint64    `json:"team_id"`
+	TeamName    string    `json:"team_name"`
+	PlayerID    uint64    `json:"player_id"`
+	PlayerName  string    `json:"player_name"`
+	PlayerEmail string    `json:"player_email"`
+	Message     string    `json:"message"`
+	Status      string    `json:"status"`
+	CreatedOn   time.Time `json:"created_on"`
+
+	// Emails of the team's managers to notify of a new request. Only set by
+	// CreateJoinRequest, as the requester can't see the team's contact details.
+	ManagerEmails []string `json:"-"`
+}
+
+// Join request statuses
+const (
+	JoinRequestPending  = "pending"
+	JoinRequestApproved = "approved"
+	JoinRequestRejected = "rejected"
+)
+
+const MaxJoinRequestMessageLength = 500
+
+type JoinRequestService interface {
+	// Creates a pending request for the user in the context to join the
+	// team. Returns ECONFLICT if they are already on the team or have a
+	// pending request.
+	CreateJoinRequest(ctx context.Context, req *JoinRequest) error
+
+	FindJoinRequests(ctx context.Context, filter JoinRequestFilter) ([]*JoinRequest, error)
+
+	// Marks a pending request of the team as approved or rejected. Approving
+	// adds the player to the team.
+	UpdateJoinRequest(ctx context.Context, teamID, id uint64, status string) (*JoinRequest, error)
+}
+
+type JoinRequestFilter struct {
+	ID       uint64  `json:"id"`
+	TeamID   uint64  `json:"team_id"`
+	PlayerID uint64  `json:"player_id"`
+	Status   *string `json:"status"`
+}
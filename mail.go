@@ -0,0 +1,18 @@
+package teamvite
+
+import "context"
+
+// An html email message
+type Mail struct {
+	Sender     string
+	SenderName string
+	To         []string
+	Subject    string
+	Body       string
+}
+
+type MailService interface {
+	// Sends the mail. Sender and SenderName default to the address of the
+	// context's organization, then the site's, when empty.
+	SendMail(ctx context.Context, m *Mail) error
+}
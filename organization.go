@@ -0,0 +1,28 @@
+package teamvite
+
+import "context"
+
+// DefaultOrganizationID is the league created with the database. Requests for
+// hosts that don't belong to another organization are for it.
+const DefaultOrganizationID = 1
+
+// An Organization is an independent league. It has its own divisions,
+// seasons and administrators, and is served from its own host. Players
+// aren't owned by an organization, they can be on teams in several.
+type Organization struct {
+	ID   uint64 `json:"id"`
+	Name string `json:"name"` // shown in place of Teamvite
+	Host string `json:"host"` // ex. pdxindoor.teamvite.com
+	// Address mail to the league's players is sent from, the site's address
+	// when empty.
+	Sender     string `json:"sender"`
+	SenderName string `json:"sender_name"`
+}
+
+type OrganizationService interface {
+	FindOrganizationByID(ctx context.Context, id uint64) (*Organization, error)
+
+	// Returns the organization served from host, or the default organization
+	// if there isn't one.
+	FindOrganizationByHost(ctx context.Context, host string) (*Organization, error)
+}
@@ -0,0 +1,50 @@
+package teamvite
+
+import (
+	"context"
+	"time"
+)
+
+// A Passkey is a WebAuthn credential a player can log in with instead of
+// their password. Players can have one per device.
+type Passkey struct {
+	ID           uint64     `json:"id"`
+	PlayerID     uint64     `json:"player_id"`
+	Name         string     `json:"name"` // the player's label for the device
+	CredentialID []byte     `json:"-"`
+	PublicKey    []byte     `json:"-"` // COSE_Key
+	SignCount    uint32     `json:"-"`
+	CreatedOn    time.Time  `json:"created_on"`
+	LastUsedOn   *time.Time `json:"last_used_on"`
+}
+
+// How long the browser has to finish creating or using a passkey
+const PasskeyChallengeLength = time.Minute * 5
+
+type PasskeyService interface {
+	// Retrieves the player's passkeys, newest first.
+	FindPasskeys(ctx context.Context, playerID uint64) ([]*Passkey, error)
+
+	// Retrieves a passkey by its credential ID. Returns ENOTFOUND if it
+	// isn't registered.
+	FindPasskeyByCredentialID(ctx context.Context, credentialID []byte) (*Passkey, error)
+
+	// Registers a passkey. Returns ECONFLICT if the credential already is.
+	CreatePasskey(ctx context.Context, passkey *Passkey) error
+
+	// Records a login with the passkey and its authenticator's new
+	// signature count.
+	UsePasskey(ctx context.Context, id uint64, signCount uint32) error
+
+	// Removes one of the player's passkeys. Returns ENOTFOUND if they don't
+	// have it.
+	DeletePasskey(ctx context.Context, playerID, id uint64) error
+
+	// Saves a challenge for registering a passkey for playerID, or for
+	// logging in when playerID is 0.
+	CreatePasskeyChallenge(ctx context.Context, challenge string, playerID uint64) error
+
+	// Uses up an unexpired challenge, returning the player it was for.
+	// Returns ENOTFOUND otherwise.
+	UsePasskeyChallenge(ctx context.Context, challenge string) (uint64, error)
+}
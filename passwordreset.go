@@ -0,0 +1,32 @@
+package teamvite
+
+import (
+	"context"
+	"time"
+)
+
+// A PasswordReset lets whoever can read the player's email set a new
+// password. Its token works once.
+type PasswordReset struct {
+	PlayerID  uint64    `json:"player_id"`
+	Email     string    `json:"email"`
+	Token     string    `json:"-"`
+	ExpiresOn time.Time `json:"expires_on"`
+}
+
+// How long the emailed reset link works for
+const PasswordResetLength = time.Hour
+
+type PasswordResetService interface {
+	// Creates a reset with a new token for the player with email. Returns
+	// ENOTFOUND if there isn't one.
+	CreatePasswordReset(ctx context.Context, email string) (*PasswordReset, error)
+
+	// Retrieves an unexpired reset by its token. Returns ENOTFOUND
+	// otherwise.
+	FindPasswordReset(ctx context.Context, token string) (*PasswordReset, error)
+
+	// Uses up the reset's token, returning the reset so the player's password
+	// can be changed. Returns ENOTFOUND if the token isn't valid.
+	UsePasswordReset(ctx context.Context, token string) (*PasswordReset, error)
+}
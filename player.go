@@ -6,11 +6,46 @@ import (
 )
 
 type Player struct {
-	ID       uint64 `db:"id,primarykey,autoincrement"`
-	Name     string `db:"name,size:64"`
-	Email    string `db:"email,size:128"`
-	Password string `db:"password,size:256,default:''"`
-	Phone    int    `db:"phone"`
+	ID       uint64 `db:"id,primarykey,autoincrement" json:"id"`
+	Name     string `db:"name,size:64" json:"name"`
+	Email    string `db:"email,size:128" json:"email"`
+	Password string `db:"password,size:256,default:''" json:"-"`
+	Phone    int    `db:"phone" json:"phone"`
+	// League administrators manage divisions
+	IsAdmin bool `json:"-"`
+
+	// Only set when finding players by team
+	IsManager bool `json:"is_manager"`
+	// Only set when finding players by team, one of the Membership statuses
+	// for the team's current season (or SeasonID from the filter)
+	SeasonStatus string `json:"season_status,omitempty"`
+	// Only set when finding players by team
+	Jersey       string `json:"jersey,omitempty"`
+	Positions    string `json:"positions,omitempty"`
+	RosterStatus string `json:"roster_status,omitempty"`
+}
+
+// Roster statuses of a player on a team. Injured reserve players stay on the
+// roster but aren't reminded or counted for games until they are reactivated.
+const (
+	RosterActive  = "active"
+	RosterInjured = "injured"
+)
+
+const MaxPositionsLength = 64
+
+// ValidJersey returns true for jersey numbers of up to 3 digits, including
+// ones with leading zeros like "00". An empty jersey means no number.
+func ValidJersey(jersey string) bool {
+	if len(jersey) > 3 {
+		return false
+	}
+	for _, c := range jersey {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
 }
 
 // A team with additional player info from players_teams
@@ -18,6 +53,26 @@ type PlayerTeam struct {
 	Team        Team
 	RemindSMS   bool
 	RemindEmail bool
+
+	// Response applied to the player's games when a game is created or enters
+	// the reminder window. One of the DefaultStatuses.
+	DefaultStatus string
+
+	// Positions the player prefers to play, free form (ex. "D, F")
+	Positions string
+}
+
+// Valid default responses for a PlayerTeam. An empty default means the player
+// is asked for every game.
+var DefaultStatuses = []string{"", "Y", "N"}
+
+func ValidDefaultStatus(status string) bool {
+	for _, s := range DefaultStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
 }
 
 func (p *Player) ItemID() uint64 {
@@ -33,10 +88,16 @@ type PlayerService interface {
 
 	// Retrieves a list of Players based on a filter. Also returns a count of total matching Teams which may
 	// differ from the number of returned Teams if the "Limit" field is set.
+	//
+	// When filtering by team or game, players are only returned if the user
+	// can see the team's roster and contact details are only returned to
+	// teammates.
 	FindPlayers(ctx context.Context, filter PlayerFilter) ([]*Player, int, error)
 
 	// Returns a list of teams that the player from the context plays on,
-	// Includes extra info such as ismanager and reminder settings
+	// Includes extra info such as ismanager and reminder settings. Hidden
+	// teams are left out unless the user plays on them or administers their
+	// league.
 	Teams(ctx context.Context, teamIDsFilter ...uint64) ([]PlayerTeam, error)
 
 	NextRemindedGame(ctx context.Context, playerID uint64) (Game, error)
@@ -46,7 +107,7 @@ type PlayerService interface {
 	// Updates a player's info
 	UpdatePlayer(ctx context.Context) error
 
-	// Update team status (reminder, is_manager, etc)
+	// Update team status (reminder, default status, is_manager, etc)
 	UpdatePlayerTeam(ctx context.Context, playerTeam *PlayerTeam) error
 
 	ResetPassword(ctx context.Context, password string) error
@@ -56,9 +117,16 @@ type PlayerFilter struct {
 	// Filtering fields.
 	ID     *uint64 `json:"id"`
 	Name   *string `json:"name"`
-	TeamID *uint64 `json:"team_id"`
-	Email  string  `json:"email"`
-	Phone  int     `json:"phone"`
+	TeamID *uint64 `json:"team_id"` // also sets IsManager on found players
+	// With TeamID, players on the team's roster for a season instead of its
+	// current roster
+	SeasonID *uint64 `json:"season_id"`
+	Email    string  `json:"email"` // ignoring case
+	Phone    int     `json:"phone"`
+
+	// Players who replied to GameID with one of GameStatuses
+	GameID       *uint64  `json:"game_id"`
+	GameStatuses []string `json:"game_statuses"`
 
 	// Restrict to subset of range.
 	Offset int `json:"offset"`
@@ -68,3 +136,11 @@ type PlayerFilter struct {
 func ReminderID(teamID uint64) string {
 	return fmt.Sprintf("reminders_%d", teamID)
 }
+
+func DefaultStatusID(teamID uint64) string {
+	return fmt.Sprintf("default_status_%d", teamID)
+}
+
+func PositionsID(teamID uint64) string {
+	return fmt.Sprintf("positions_%d", teamID)
+}
@@ -2,41 +2,36 @@ package reminders
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"html/template"
-	"io"
 	"log"
-	"net/http"
-	"net/smtp"
 	"net/url"
 	"strings"
 	"time"
 
 	"github.com/benprew/teamvite"
 	thttp "github.com/benprew/teamvite/http"
+	"github.com/benprew/teamvite/smtp"
 	"github.com/benprew/teamvite/sqlite"
+	"github.com/benprew/teamvite/twilio"
 )
 
-type mail struct {
-	Sender     string
-	SenderName string
-	To         []string
-	Subject    string
-	Body       string
-}
+// Games starting within the window get reminders.
+const ReminderWindow = time.Hour * 24 * 5
 
 type ReminderService struct {
 	db     *sql.DB
-	smtp   teamvite.SMTPConfig
-	sms    teamvite.SMSConfig
+	mail   teamvite.MailService
+	sms    teamvite.SMSService
 	domain string
 }
 
 // NewGameService returns a new instance of GameService.
 func NewReminderService(db *sql.DB, SMTP teamvite.SMTPConfig, SMS teamvite.SMSConfig, domain string) *ReminderService {
-	return &ReminderService{db: db, smtp: SMTP, sms: SMS, domain: domain}
+	return &ReminderService{db: db, mail: smtp.NewMailService(SMTP), sms: twilio.NewSMSService(SMS), domain: domain}
 }
 
 func checkErr(err error, msg string) {
@@ -46,6 +41,11 @@ func checkErr(err error, msg string) {
 }
 
 func (s *ReminderService) SendGameReminders() error {
+	if err := s.applyDefaultStatuses(); err != nil {
+		log.Println("applying default statuses:", err)
+		return err
+	}
+
 	query := `
 	SELECT
 		p.id AS player_id,
@@ -57,18 +57,23 @@ func (s *ReminderService) SendGameReminders() error {
 		g.description AS game_description,
 		t.name AS team_name,
 		t.division_id AS division_id,
+		d.organization_id AS organization_id,
 		pt.remind_email AS remind_email,
-		pt.remind_sms AS remind_sms
+		pt.remind_sms AS remind_sms,
+		pg.status AS status
 	FROM players p
 	JOIN players_games pg ON p.id = pg.player_id
 	JOIN games g ON pg.game_id = g.id
-	JOIN players_teams pt ON p.id = pt.player_id
+	JOIN players_teams pt ON p.id = pt.player_id AND pt.team_id = g.team_id
 	JOIN teams t ON pt.team_id = t.id
+	JOIN divisions d ON t.division_id = d.id
 	WHERE
-		g.time BETWEEN datetime('now') AND datetime('now', '+5 days')
+		g.time BETWEEN datetime('now') AND datetime('now', ?)
+		AND pt.roster_status = 'active'
 		AND (NOT pg.reminder_sent OR pg.status = '');
 	`
-	rows, err := s.db.Query(query)
+	window := fmt.Sprintf("+%d seconds", int(ReminderWindow.Seconds()))
+	rows, err := s.db.Query(query, window)
 	if err != nil {
 		log.Println("querying for reminders:", err)
 		return err
@@ -80,14 +85,19 @@ func (s *ReminderService) SendGameReminders() error {
 	messages := make(map[string]string, 1000)
 	reminders := []string{}
 	var mKey string
+	// reminders are branded for the team's league
+	orgService := sqlite.NewOrganizationService(s.db)
+	orgs := map[uint64]*teamvite.Organization{}
 
 	for rows.Next() {
 		var p teamvite.Player
 		var g teamvite.Game
 		var tName string
 		var divID int
+		var orgID uint64
 		var remindEmail bool
 		var remindSMS bool
+		var status string
 		err := rows.Scan(
 			&p.ID,
 			&p.Name,
@@ -98,17 +108,29 @@ func (s *ReminderService) SendGameReminders() error {
 			&g.Description,
 			&tName,
 			&divID,
+			&orgID,
 			&remindEmail,
-			&remindSMS)
+			&remindSMS,
+			&status)
 		if err != nil {
 			log.Println("reading reminder rows", err)
 			return err
 		}
 
+		org, ok := orgs[orgID]
+		if !ok {
+			if org, err = orgService.FindOrganizationByID(context.Background(), orgID); err != nil {
+				log.Println("finding organization for reminders:", err)
+				return err
+			}
+			orgs[orgID] = org
+		}
+		ctx := teamvite.NewContextWithOrganization(context.Background(), org)
+
 		mKey = fmt.Sprintf("%s-%d", tName, divID)
 		reminderSent := false
 		if remindEmail {
-			if err := s.emailReminder(p, g); err != nil {
+			if err := s.emailReminder(ctx, p, g, status); err != nil {
 				checkErr(err, "Sending email")
 			} else {
 				reminderSent = true
@@ -117,7 +139,7 @@ func (s *ReminderService) SendGameReminders() error {
 		}
 
 		if remindSMS {
-			if err := s.sendTwilioSMSMessage(p, g); err != nil {
+			if err := s.sendTwilioSMSMessage(ctx, p, g, status); err != nil {
 				checkErr(err, "Sending SMS")
 			} else {
 				reminderSent = true
@@ -155,37 +177,47 @@ func (s *ReminderService) SendGameReminders() error {
 	return nil
 }
 
-func (s *ReminderService) emailReminder(p teamvite.Player, g teamvite.Game) error {
+// applyDefaultStatuses marks players with a default response as coming (or
+// not) once a game enters the reminder window, so their reminder can say so.
+func (s *ReminderService) applyDefaultStatuses() error {
+	gs := sqlite.NewGameService(s.db)
+	return gs.ApplyUpcomingDefaultStatuses(context.Background(), ReminderWindow)
+}
+
+func (s *ReminderService) emailReminder(ctx context.Context, p teamvite.Player, g teamvite.Game, status string) error {
 	log.Printf("Sending reminder to: %s\n", p.Email)
-	sessionService := sqlite.NewSessionService(s.db)
-	session, err := sessionService.New(p.ID, nil, time.Hour*24*7)
+	// the links can only reply to this game, so a forwarded reminder doesn't
+	// let anyone else act as the player
+	tokenService := sqlite.NewActionTokenService(s.db)
+	token, err := tokenService.CreateActionToken(ctx, p.ID, g.ID, teamvite.ActionRSVP)
 	if err != nil {
 		log.Println("creating token: ", err)
 		return err
 	}
-	reminderURL := fmt.Sprintf("https://%s%s?%s=%s", s.domain, thttp.UrlFor(&g, "show"), thttp.SESSION_KEY, session.ID)
-	body, err := reminderEmailBody(reminderParams{Player: &p, Game: &g, ReminderURL: reminderURL})
+	org := teamvite.OrganizationFromContext(ctx)
+	domain := s.domain
+	if org.Host != "" {
+		domain = org.Host
+	}
+	reminderURL := fmt.Sprintf("https://%s%s?%s=%s", domain, thttp.UrlFor(&g, "show"), thttp.ACTION_TOKEN_KEY, url.QueryEscape(token.Token))
+	body, err := reminderEmailBody(reminderParams{Player: &p, Game: &g, Status: status, ReminderURL: reminderURL, League: org.Name})
 	if err != nil {
 		log.Println("building reminder email body: ", err)
 		return err
 	}
-	request := mail{
-		Sender:     "team@teamvite.com",
-		SenderName: "Teamvite",
-		To:         []string{p.Email},
-		Subject:    fmt.Sprintf("Next Game: %s %s", g.Time.Format(""), g.Description),
-		Body:       body,
-	}
-	msg := buildMessage(request)
-	auth := smtp.PlainAuth("", s.smtp.Username, s.smtp.Password, s.smtp.Hostname)
-	addr := fmt.Sprintf("%s:%d", s.smtp.Hostname, s.smtp.Port)
-	return smtp.SendMail(addr, auth, request.Sender, request.To, []byte(msg))
+	return s.mail.SendMail(ctx, &teamvite.Mail{
+		To:      []string{p.Email},
+		Subject: fmt.Sprintf("Next Game: %s %s", g.Time.Format(""), g.Description),
+		Body:    body,
+	})
 }
 
 type reminderParams struct {
 	Player      *teamvite.Player
 	Game        *teamvite.Game
+	Status      string
 	ReminderURL string
+	League      string
 }
 
 var reminderTemplate = `
@@ -195,7 +227,13 @@ This is your game reminder.  The next game is:<br>
   {{ .Game.Time.Format "Mon Jan 2 3:04PM" }} {{ .Game.Description }}
 </blockquote>
 
+{{ if eq .Status "Y" }}
+You're marked as coming. If that changes, click No.
+{{ else if eq .Status "N" }}
+You're marked as not coming. If that changes, click Yes.
+{{ else }}
 Can you make the game?
+{{ end }}
 <ul>
   <li><a href="{{ statusURL .ReminderURL "Y" }}">Yes</a></li>
   <li><a href="{{ statusURL .ReminderURL "N" }}">No</a></li>
@@ -203,7 +241,7 @@ Can you make the game?
 </ul>
 
 
-Thank you for using Teamvite!
+Thank you for using {{ .League }}!
 `
 
 func statusURL(URL string, status string) string {
@@ -231,79 +269,40 @@ func reminderEmailBody(params reminderParams) (string, error) {
 	return w.String(), nil
 }
 
-func buildMessage(mail mail) string {
-	msg := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\r\n"
-	msg += fmt.Sprintf("From: %s <%s>\r\n", mail.SenderName, mail.Sender)
-	msg += fmt.Sprintf("To: %s\r\n", strings.Join(mail.To, ";"))
-	msg += fmt.Sprintf("Subject: %s\r\n", mail.Subject)
-	msg += fmt.Sprintf("\r\n%s\r\n", mail.Body)
-
-	return msg
-}
-
-func (s *ReminderService) sendTwilioSMSMessage(p teamvite.Player, g teamvite.Game) error {
-	accountSid := s.sms.Sid
-	u := fmt.Sprintf("%s/Accounts/%s/Messages.json", s.sms.API, accountSid)
-	client := &http.Client{Timeout: time.Second * 10}
-
-	body, err := smsBody(g)
+func (s *ReminderService) sendTwilioSMSMessage(ctx context.Context, p teamvite.Player, g teamvite.Game, status string) error {
+	body, err := smsBody(smsParams{Game: g, Status: status, League: teamvite.OrganizationFromContext(ctx).Name})
 	if err != nil {
 		return err
 	}
+	return s.sms.SendSMS(ctx, p.Phone, body)
+}
 
-	// HTTP requests to the API are protected with HTTP Basic
-	// authentication. To learn more about how Twilio handles authentication,
-	// please refer to our security documentation.
-	//
-	// In short, you will use your Twilio Account SID as the username and your
-	// Auth Token as the password for HTTP Basic authentication with Twilio.
-	//
-	// curl -G https://api.twilio.com/2010-04-01/Accounts \
-	//   -u <YOUR_ACCOUNT_SID>:<YOUR_AUTH_TOKEN>
-	postParams := url.Values{}
-	postParams.Add("To", fmt.Sprintf("+1%d", p.Phone))
-	postParams.Add("From", s.sms.From)
-	postParams.Add("Body", body)
-	postParams.Add("StatusCallback", "https://www.teamvite.com/sms")
-
-	log.Println(postParams)
-	log.Println(u)
-	req, err := http.NewRequest("POST", u, strings.NewReader(postParams.Encode()))
-	if req == nil {
-		return err
-	}
-	checkErr(err, "creating twilio post")
-	req.SetBasicAuth(s.sms.Sid, s.sms.Token)
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	resp, err := client.Do(req)
-	if resp == nil {
-		return err
-	}
-	checkErr(err, "posting to SMS gateway")
-
-	defer resp.Body.Close()
-
-	respBody, err := io.ReadAll(resp.Body)
-	checkErr(err, "SMS gateway response")
-	log.Println(string(respBody))
-
-	return err
+type smsParams struct {
+	Game   teamvite.Game
+	Status string
+	League string
 }
 
 var smsReminderTemplate = `
-Teamvite Game Reminder:
-{{ .Time.Format "Mon Jan 2 3:04PM" }} {{ .Description }}
+{{ .League }} Game Reminder:
+{{ .Game.Time.Format "Mon Jan 2 3:04PM" }} {{ .Game.Description }}
+{{- if eq .Status "Y" }}
+You're marked as coming, reply NO to change
+{{- else if eq .Status "N" }}
+You're marked as not coming, reply YES to change
+{{- else }}
 Reply
-YES/NO/MAYBE/STOP`
+YES/NO/MAYBE/STOP
+{{- end }}`
 
-func smsBody(g teamvite.Game) (string, error) {
+func smsBody(params smsParams) (string, error) {
 	tmpl, err := template.New("content").Parse(smsReminderTemplate)
 	if err != nil {
 		checkErr(err, "parsing smsReminderTemplate")
 		return "", err
 	}
 	var w bytes.Buffer
-	if err = tmpl.ExecuteTemplate(&w, "content", g); err != nil {
+	if err = tmpl.ExecuteTemplate(&w, "content", params); err != nil {
 		log.Println("[ERROR]", err)
 		return "", err
 	}
@@ -0,0 +1,121 @@
+package teamvite
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+)
+
+// A player read from a roster CSV file
+type RosterEntry struct {
+	Line      int
+	Name      string
+	Email     string
+	Phone     int
+	IsManager bool
+
+	// Why the entry can't be imported, empty if it is valid
+	Problem string
+}
+
+// Column order of roster CSV files, used when a file has no header row
+var RosterColumns = []string{"name", "email", "phone", "manager"}
+
+// ParseRoster reads a roster CSV with name, email, phone and manager
+// columns. The header row is optional, when present columns can be in any
+// order. Rows with problems are returned with Problem set rather than
+// failing the whole file.
+func ParseRoster(r io.Reader) ([]RosterEntry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	records, lines := [][]string{}, []int{}
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				return nil, Errorf(EINVALID, "Invalid CSV on line %d: %s", perr.Line, perr.Err)
+			}
+			return nil, err
+		}
+		line, _ := cr.FieldPos(0)
+		records, lines = append(records, record), append(lines, line)
+	}
+
+	columns := map[string]int{}
+	for i, c := range RosterColumns {
+		columns[c] = i
+	}
+	start := 0
+	if len(records) > 0 && isRosterHeader(records[0]) {
+		columns = map[string]int{}
+		for i, c := range records[0] {
+			c = strings.ToLower(strings.TrimSpace(c))
+			if c == "is_manager" {
+				c = "manager"
+			}
+			columns[c] = i
+		}
+		start = 1
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	entries := []RosterEntry{}
+	for i := start; i < len(records); i++ {
+		record := records[i]
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		e := RosterEntry{
+			Line:      lines[i],
+			Name:      field(record, "name"),
+			Email:     field(record, "email"),
+			IsManager: parseRosterFlag(field(record, "manager")),
+		}
+		if phone := field(record, "phone"); phone != "" {
+			if e.Phone = UnTelify(phone); e.Phone == -1 {
+				e.Phone = 0
+				e.Problem = fmt.Sprintf("Invalid phone number %q, must be 10 digits", phone)
+			}
+		}
+		if e.Email == "" {
+			e.Problem = "Email is required"
+		} else if addr, err := mail.ParseAddress(e.Email); err != nil || addr.Address != e.Email {
+			e.Problem = fmt.Sprintf("Invalid email %q", e.Email)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func isRosterHeader(record []string) bool {
+	for _, c := range record {
+		if strings.EqualFold(strings.TrimSpace(c), "email") {
+			return true
+		}
+	}
+	return false
+}
+
+func parseRosterFlag(s string) bool {
+	switch strings.ToLower(s) {
+	case "y", "yes", "true", "1", "x", "manager":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package teamvite
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRoster(t *testing.T) {
+	csv := `Email,Name,Manager,Phone
+alice@example.com,Alice,yes,503-555-1111
+bob@example.com,Bob,,
+
+not-an-email,Carol,,
+dan@example.com,Dan,,555-1234
+`
+	entries, err := ParseRoster(strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("got %d entries, want 4: %v", len(entries), entries)
+	}
+
+	alice := entries[0]
+	if alice.Name != "Alice" || alice.Email != "alice@example.com" || alice.Phone != 5035551111 || !alice.IsManager || alice.Problem != "" {
+		t.Errorf("unexpected entry for alice: %+v", alice)
+	}
+	if entries[1].IsManager || entries[1].Problem != "" {
+		t.Errorf("unexpected entry for bob: %+v", entries[1])
+	}
+	if entries[2].Line != 5 || entries[2].Problem == "" {
+		t.Errorf("expected invalid email on line 5: %+v", entries[2])
+	}
+	if entries[3].Problem == "" {
+		t.Errorf("expected invalid phone: %+v", entries[3])
+	}
+}
+
+func TestParseRosterWithoutHeader(t *testing.T) {
+	entries, err := ParseRoster(strings.NewReader("Alice,alice@example.com,5035551111,y\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != "Alice" || entries[0].Phone != 5035551111 || !entries[0].IsManager {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+}
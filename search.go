@@ -0,0 +1,35 @@
+package teamvite
+
+import "context"
+
+// A team, division or player matching a search.
+type SearchResult struct {
+	Type string `json:"type"` // team, division or player
+	ID   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+// Search result types
+const (
+	SearchTeam     = "team"
+	SearchDivision = "division"
+	SearchPlayer   = "player"
+)
+
+// for urlFor
+func (r *SearchResult) ItemID() uint64 {
+	return r.ID
+}
+
+func (r *SearchResult) ItemType() string {
+	return r.Type
+}
+
+const MaxSearchResults = 50
+
+type SearchService interface {
+	// Returns teams, divisions and players whose names start with the words
+	// of the query, best match first. Hidden teams are only found by their
+	// members and players are only found by their teammates.
+	Search(ctx context.Context, query string, limit int) ([]*SearchResult, error)
+}
@@ -1,19 +1,52 @@
 package teamvite
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type Season struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID             int    `json:"id"`
+	OrganizationID uint64 `json:"organization_id"`
+	Name           string `json:"name"`
+	// Dates the season is played, zero for seasons that predate them
+	StartOn time.Time `json:"start_on"`
+	EndOn   time.Time `json:"end_on"`
+	// Closed seasons are over, games can't be added to them and they are
+	// left out of upcoming games.
+	Closed bool `json:"closed"`
+}
+
+// for urlFor
+func (s *Season) ItemID() uint64 {
+	return uint64(s.ID)
+}
+
+func (s *Season) ItemType() string {
+	return "season"
 }
 
 // interface for the season service
 type SeasonService interface {
 	FindSeasons(ctx context.Context, filter SeasonFilter) ([]*Season, int, error)
+
+	// Returns the open season being played today, or the open season that
+	// started most recently if none are, or the next one to start between
+	// seasons. Returns ENOTFOUND if every season is closed.
+	CurrentSeason(ctx context.Context) (*Season, error)
+
+	// Creates a new open Season. Only league administrators can create
+	// seasons.
+	CreateSeason(ctx context.Context, season *Season) error
+
+	// Closes a Season once it is over. Only league administrators can close
+	// seasons.
+	CloseSeason(ctx context.Context, id uint64) (*Season, error)
 }
 
 // filter for the season service
 type SeasonFilter struct {
-	ID   *uint64
-	Name string
+	ID     *uint64
+	Name   string
+	Closed *bool
 }
@@ -1,6 +1,8 @@
 package teamvite
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"net"
 	"time"
 )
@@ -34,6 +36,25 @@ type Session struct {
 	IpStr     string    `db:"ip"`
 	ExpiresOn time.Time `db:"expires_on"`
 	IP        net.IP
+
+	// The player entered a two-factor code when logging in
+	TwoFactor bool `db:"two_factor"`
+
+	CreatedOn  time.Time `db:"created_on"`   // zero for sessions made before it was recorded
+	LastSeenOn time.Time `db:"last_seen_on"` // zero if never used
+}
+
+// Key refers to the session on pages without giving away its ID. It's a hash
+// of the ID, so unlike the rowid it doesn't change when the table is vacuumed.
+func (s Session) Key() string {
+	sum := sha256.Sum256([]byte(s.ID))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Sessions from links in reminders were made without an IP, before reminders
+// used action tokens.
+func (s Session) FromReminder() bool {
+	return s.IP == nil
 }
 
 // Creating a Session
@@ -75,4 +96,37 @@ type SessionService interface {
 	Load(sid string, ip net.IP) (Session, error)
 
 	Revoke(sid string) error
+
+	// Revokes every session of the player, ex. when their password changes.
+	RevokeAll(playerID uint64) error
+
+	// NewLoginToken saves a single-use token to email to the player. It
+	// can't be loaded as a session, only redeemed for one within tokenLen.
+	NewLoginToken(playerID uint64, tokenLen time.Duration) (Session, error)
+
+	// RedeemLoginToken uses up the login token and returns a new session
+	// of its player from IP.
+	RedeemLoginToken(token string, IP net.IP, sessionLen time.Duration) (Session, error)
+
+	// FindLoginToken returns an unexpired login token without using it up,
+	// ex. to ask for a two-factor code before redeeming it.
+	FindLoginToken(token string) (Session, error)
+
+	// MarkTwoFactor records that the session's player entered a two-factor
+	// code.
+	MarkTwoFactor(sid string) error
+
+	// FindSessions returns the player's unexpired sessions, most recently
+	// seen first.
+	FindSessions(playerID uint64) ([]Session, error)
+
+	// Touch records that the session was just used.
+	Touch(sid string) error
+
+	// RevokeSession revokes the player's session with key. Returns
+	// ENOTFOUND if they don't have it.
+	RevokeSession(playerID uint64, key string) error
+
+	// RevokeOthers revokes every session of the player except sid.
+	RevokeOthers(playerID uint64, sid string) error
 }
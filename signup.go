@@ -0,0 +1,38 @@
+package teamvite
+
+import (
+	"context"
+	"time"
+)
+
+// A Signup is a request for an account that waits for its email to be
+// verified. Nothing is created until it is, so an email can't be claimed by
+// someone who doesn't own it.
+type Signup struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"-"` // bcrypt hash once created
+	Phone    int    `json:"phone"`
+	Token    string `json:"-"`
+	// When the verification link stops working
+	ExpiresOn time.Time `json:"expires_on"`
+}
+
+// How long the verification link of a signup works for
+const SignupLength = time.Hour * 24
+
+const MinPasswordLength = 8
+
+type SignupService interface {
+	// Validates the signup and stores it with a new verification token.
+	// Password is the plain text password and is replaced by its hash.
+	// Returns ECONFLICT if the email already has an account with a password,
+	// callers shouldn't reveal that to whoever is signing up.
+	CreateSignup(ctx context.Context, signup *Signup) error
+
+	// Verifies the email of the signup with token and returns its account.
+	// A player added by a manager (with no password yet) is given the
+	// signup's name, password and phone rather than creating another player.
+	// Returns EINVALID if another player has the signup's phone.
+	VerifySignup(ctx context.Context, token string) (*Player, error)
+}
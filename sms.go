@@ -1,5 +1,12 @@
 package teamvite
 
+import "context"
+
+type SMSService interface {
+	// Sends a text message to a 10 digit phone number
+	SendSMS(ctx context.Context, phone int, body string) error
+}
+
 // func (s *Server) SMS() http.Handler {
 // 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 // 		// TODO: check basic auth
@@ -0,0 +1,59 @@
+package smtp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/smtp"
+	"strings"
+
+	"github.com/benprew/teamvite"
+)
+
+const (
+	DefaultSender     = "team@teamvite.com"
+	DefaultSenderName = "Teamvite"
+)
+
+type MailService struct {
+	conf teamvite.SMTPConfig
+}
+
+// Ensure service implements interface.
+var _ teamvite.MailService = (*MailService)(nil)
+
+// NewMailService returns a new instance of MailService.
+func NewMailService(conf teamvite.SMTPConfig) *MailService {
+	return &MailService{conf: conf}
+}
+
+func (s *MailService) SendMail(ctx context.Context, m *teamvite.Mail) error {
+	if len(m.To) == 0 {
+		return teamvite.Errorf(teamvite.EINVALID, "mail has no recipients")
+	}
+	// mail for a league comes from its address
+	if org := teamvite.OrganizationFromContext(ctx); org != nil && m.Sender == "" && org.Sender != "" {
+		m.Sender, m.SenderName = org.Sender, org.SenderName
+	}
+	if m.Sender == "" {
+		m.Sender = DefaultSender
+	}
+	if m.SenderName == "" {
+		m.SenderName = DefaultSenderName
+	}
+
+	log.Printf("Sending mail [subject=%s, recipients=%d]\n", m.Subject, len(m.To))
+	auth := smtp.PlainAuth("", s.conf.Username, s.conf.Password, s.conf.Hostname)
+	addr := fmt.Sprintf("%s:%d", s.conf.Hostname, s.conf.Port)
+	return smtp.SendMail(addr, auth, m.Sender, m.To, []byte(buildMessage(m)))
+}
+
+func buildMessage(mail *teamvite.Mail) string {
+	msg := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\r\n"
+	msg += fmt.Sprintf("From: %s <%s>\r\n", mail.SenderName, mail.Sender)
+	msg += fmt.Sprintf("To: %s\r\n", strings.Join(mail.To, ";"))
+	msg += fmt.Sprintf("Subject: %s\r\n", mail.Subject)
+	msg += fmt.Sprintf("\r\n%s\r\n", mail.Body)
+
+	return msg
+}
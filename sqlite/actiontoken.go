@@ -0,0 +1,53 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/benprew/teamvite"
+)
+
+type ActionTokenService struct {
+	db *sql.DB
+}
+
+// Ensure service implements interface.
+var _ teamvite.ActionTokenService = (*ActionTokenService)(nil)
+
+// NewActionTokenService returns a new instance of ActionTokenService.
+func NewActionTokenService(db *sql.DB) *ActionTokenService {
+	return &ActionTokenService{db: db}
+}
+
+func (s *ActionTokenService) CreateActionToken(ctx context.Context, playerID, gameID uint64, action string) (*teamvite.ActionToken, error) {
+	token := teamvite.ActionToken{
+		Token:     genToken(),
+		PlayerID:  playerID,
+		GameID:    gameID,
+		Action:    action,
+		ExpiresOn: time.Now().UTC().Add(teamvite.ActionTokenLength),
+	}
+	_, err := s.db.ExecContext(ctx,
+		"insert into action_tokens (token, player_id, game_id, action, expires_on) values (?, ?, ?, ?, ?)",
+		token.Token, token.PlayerID, token.GameID, token.Action, token.ExpiresOn.Unix())
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	return &token, nil
+}
+
+func (s *ActionTokenService) FindActionToken(ctx context.Context, token string, gameID uint64, action string) (*teamvite.ActionToken, error) {
+	t := teamvite.ActionToken{Token: token}
+	err := s.db.QueryRowContext(ctx, `
+		select player_id, game_id, action, expires_on
+		from action_tokens
+		where token = ? and game_id = ? and action = ?`, token, gameID, action,
+	).Scan(&t.PlayerID, &t.GameID, &t.Action, &t.ExpiresOn)
+	if err == sql.ErrNoRows || (err == nil && time.Now().After(t.ExpiresOn)) {
+		return nil, teamvite.Errorf(teamvite.ENOTFOUND, "That link isn't valid, it may have expired. Log in to reply.")
+	} else if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
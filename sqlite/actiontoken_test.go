@@ -0,0 +1,43 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benprew/teamvite"
+)
+
+func TestActionToken(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into players (id, name, email) values (1, 'Player', 'p@example.com');
+		insert into teams (id, name, division_id) values (1, 'Team', 1);
+		insert into games (id, team_id, season_id, time) values (1, 1, 1, 0), (2, 1, 1, 1);`)
+	panicIf(err)
+
+	ts := NewActionTokenService(db)
+	ctx := context.Background()
+	token, err := ts.CreateActionToken(ctx, 1, 1, teamvite.ActionRSVP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the token can be used more than once, to change a reply
+	for i := 0; i < 2; i++ {
+		if found, err := ts.FindActionToken(ctx, token.Token, 1, teamvite.ActionRSVP); err != nil || found.PlayerID != 1 {
+			t.Errorf("find token = %v, %v", found, err)
+		}
+	}
+	// but only for its game and action
+	if _, err := ts.FindActionToken(ctx, token.Token, 2, teamvite.ActionRSVP); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("other game: err = %v; want not found", err)
+	}
+	if _, err := ts.FindActionToken(ctx, token.Token, 1, "other"); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("other action: err = %v; want not found", err)
+	}
+
+	_, err = db.Exec("update action_tokens set expires_on = ? where token = ?", token.ExpiresOn.AddDate(0, 0, -8).Unix(), token.Token)
+	panicIf(err)
+	if _, err := ts.FindActionToken(ctx, token.Token, 1, teamvite.ActionRSVP); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("expired: err = %v; want not found", err)
+	}
+}
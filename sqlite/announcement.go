@@ -0,0 +1,172 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/benprew/teamvite"
+)
+
+type AnnouncementService struct {
+	db *sql.DB
+}
+
+// Ensure service implements interface.
+var _ teamvite.AnnouncementService = (*AnnouncementService)(nil)
+
+// NewAnnouncementService returns a new instance of AnnouncementService.
+func NewAnnouncementService(db *sql.DB) *AnnouncementService {
+	return &AnnouncementService{db: db}
+}
+
+func (s *AnnouncementService) FindAnnouncements(ctx context.Context, teamID uint64) ([]*teamvite.Announcement, error) {
+	return s.findAnnouncements(ctx, "WHERE a.team_id = ?", "ORDER BY a.created_on DESC, a.id DESC", teamID)
+}
+
+func (s *AnnouncementService) FindPendingAnnouncements(ctx context.Context) ([]*teamvite.Announcement, error) {
+	return s.findAnnouncements(ctx, "", "HAVING count(CASE WHEN d.status = 'pending' THEN 1 END) > 0 ORDER BY a.id")
+}
+
+// findAnnouncements returns announcements with their delivery counts. where
+// filters announcements, tail follows the GROUP BY.
+func (s *AnnouncementService) findAnnouncements(ctx context.Context, where, tail string, args ...interface{}) ([]*teamvite.Announcement, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			a.id, a.team_id, a.player_id, p.name, a.body, a.created_on,
+			count(CASE WHEN d.status = 'sent' THEN 1 END),
+			count(CASE WHEN d.status = 'failed' THEN 1 END),
+			count(CASE WHEN d.status = 'pending' THEN 1 END)
+		FROM announcements a
+		JOIN players p ON a.player_id = p.id
+		LEFT JOIN announcements_deliveries d ON d.announcement_id = a.id
+		`+where+`
+		GROUP BY a.id
+		`+tail,
+		args...,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	announcements := make([]*teamvite.Announcement, 0)
+	for rows.Next() {
+		var a teamvite.Announcement
+		if err := rows.Scan(
+			&a.ID, &a.TeamID, &a.PlayerID, &a.PlayerName, &a.Body, &a.CreatedOn,
+			&a.Sent, &a.Failed, &a.Pending,
+		); err != nil {
+			return nil, err
+		}
+		announcements = append(announcements, &a)
+	}
+	return announcements, rows.Err()
+}
+
+func (s *AnnouncementService) CreateAnnouncement(ctx context.Context, a *teamvite.Announcement) error {
+	user := teamvite.UserFromContext(ctx)
+	if user == nil {
+		return teamvite.Errorf(teamvite.EUNAUTHORIZED, "Must be logged in to send an announcement")
+	}
+	a.Body = strings.TrimSpace(a.Body)
+	if a.Body == "" {
+		return teamvite.Errorf(teamvite.EINVALID, "Announcement is required")
+	}
+	if len(a.Body) > teamvite.MaxAnnouncementLength {
+		return teamvite.Errorf(teamvite.EINVALID, "Announcement must be %d characters or less", teamvite.MaxAnnouncementLength)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	isMgr, err := isManager(ctx, tx, user.ID, a.TeamID)
+	if err != nil {
+		return err
+	}
+	if !isMgr {
+		return teamvite.Errorf(teamvite.EUNAUTHORIZED, "Only managers can send announcements")
+	}
+
+	a.PlayerID = user.ID
+	a.PlayerName = user.Name
+	a.CreatedOn = time.Now()
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO announcements (team_id, player_id, body, created_on)
+		VALUES (?, ?, ?, ?)`,
+		a.TeamID, a.PlayerID, a.Body, a.CreatedOn,
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	a.ID = uint64(id)
+
+	result, err = tx.ExecContext(ctx, `
+		INSERT INTO announcements_deliveries (announcement_id, player_id, channel)
+		SELECT ?, pt.player_id, 'email'
+		FROM players_teams pt
+		JOIN players p ON pt.player_id = p.id
+		WHERE pt.team_id = ? AND pt.remind_email AND p.email != ''
+		UNION ALL
+		SELECT ?, pt.player_id, 'sms'
+		FROM players_teams pt
+		JOIN players p ON pt.player_id = p.id
+		WHERE pt.team_id = ? AND pt.remind_sms AND p.phone != 0`,
+		a.ID, a.TeamID, a.ID, a.TeamID,
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	a.Pending = int(n)
+
+	return tx.Commit()
+}
+
+func (s *AnnouncementService) Deliveries(ctx context.Context, announcementID uint64) ([]*teamvite.Delivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT d.announcement_id, d.player_id, p.name, p.email, p.phone, d.channel, d.status
+		FROM announcements_deliveries d
+		JOIN players p ON d.player_id = p.id
+		WHERE d.announcement_id = ?
+		ORDER BY p.name, d.channel`,
+		announcementID,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*teamvite.Delivery, 0)
+	for rows.Next() {
+		var d teamvite.Delivery
+		if err := rows.Scan(
+			&d.AnnouncementID, &d.PlayerID, &d.PlayerName, &d.Email, &d.Phone, &d.Channel, &d.Status,
+		); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, rows.Err()
+}
+
+func (s *AnnouncementService) UpdateDelivery(ctx context.Context, d *teamvite.Delivery) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE announcements_deliveries
+		SET status = ?, updated_on = ?
+		WHERE announcement_id = ? AND player_id = ? AND channel = ?`,
+		d.Status, time.Now(), d.AnnouncementID, d.PlayerID, d.Channel,
+	)
+	return FormatError(err)
+}
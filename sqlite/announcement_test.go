@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benprew/teamvite"
+)
+
+func TestCreateAnnouncement(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into divisions (id, name) values (1, 'm1');
+		insert into teams (id, name, division_id) values (1, 'Blue', 1), (2, 'Red', 1);
+		insert into players (id, name, email, phone) values (1, 'Manager', 'm@example.com', 0), (2, 'Player', 'p@example.com', 5035551111), (3, 'Outsider', 'o@example.com', 0);
+		insert into players_teams (player_id, team_id, is_manager, remind_email, remind_sms) values (1, 1, true, true, false), (2, 1, false, true, true), (3, 2, true, true, false);`)
+	panicIf(err)
+
+	as := NewAnnouncementService(db)
+	ctx := func(id uint64) context.Context {
+		return teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: id})
+	}
+
+	if err := as.CreateAnnouncement(context.Background(), &teamvite.Announcement{TeamID: 1, Body: "hi"}); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+		t.Errorf("anonymous announcement: err = %v; want unauthorized", err)
+	}
+	if err := as.CreateAnnouncement(ctx(2), &teamvite.Announcement{TeamID: 1, Body: "hi"}); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+		t.Errorf("non-manager announcement: err = %v; want unauthorized", err)
+	}
+	// managing another team doesn't count
+	if err := as.CreateAnnouncement(ctx(3), &teamvite.Announcement{TeamID: 1, Body: "hi"}); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+		t.Errorf("non-member announcement: err = %v; want unauthorized", err)
+	}
+
+	a := teamvite.Announcement{TeamID: 1, Body: "No game Friday"}
+	if err := as.CreateAnnouncement(ctx(1), &a); err != nil {
+		t.Fatal(err)
+	}
+	// email for both players, sms for the one with it on
+	if a.Pending != 3 {
+		t.Errorf("pending deliveries = %d; want 3", a.Pending)
+	}
+	announcements, err := as.FindAnnouncements(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(announcements) != 1 || announcements[0].PlayerID != 1 {
+		t.Errorf("announcements = %v", announcements)
+	}
+
+	pending, err := as.FindPendingAnnouncements(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].ID != a.ID || pending[0].Pending != 3 {
+		t.Errorf("pending announcements = %v; want %d", pending, a.ID)
+	}
+
+	// once every delivery has an outcome there's nothing left to resume
+	deliveries, err := as.Deliveries(context.Background(), a.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range deliveries {
+		d.Status = teamvite.DeliverySent
+		if err := as.UpdateDelivery(context.Background(), d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pending, err = as.FindPendingAnnouncements(context.Background()); err != nil || len(pending) != 0 {
+		t.Errorf("pending announcements after sending = %v, %v; want none", pending, err)
+	}
+}
@@ -0,0 +1,182 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/benprew/teamvite"
+)
+
+type CalendarFeedService struct {
+	db *sql.DB
+}
+
+// Ensure service implements interface.
+var _ teamvite.CalendarFeedService = (*CalendarFeedService)(nil)
+
+// NewCalendarFeedService returns a new instance of CalendarFeedService.
+func NewCalendarFeedService(db *sql.DB) *CalendarFeedService {
+	return &CalendarFeedService{db: db}
+}
+
+func (s *CalendarFeedService) CreateCalendarFeed(ctx context.Context, teamID uint64) (*teamvite.CalendarFeed, error) {
+	playerID := teamvite.UserIDFromContext(ctx)
+	if playerID == 0 {
+		return nil, teamvite.Errorf(teamvite.EUNAUTHORIZED, "You must be logged in to subscribe to a calendar.")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// personal feeds don't have a team
+	var team interface{}
+	if teamID != 0 {
+		var onTeam bool
+		err = tx.QueryRowContext(ctx,
+			"select exists (select 1 from players_teams where team_id = ? and player_id = ?)",
+			teamID, playerID,
+		).Scan(&onTeam)
+		if err != nil {
+			return nil, err
+		}
+		if !onTeam {
+			return nil, teamvite.Errorf(teamvite.EUNAUTHORIZED, "You must be on the team to subscribe to its calendar.")
+		}
+		team = teamID
+	}
+
+	// a new token replaces the old one, so existing subscriptions stop working
+	token, now := genToken(), time.Now().UTC()
+	result, err := tx.ExecContext(ctx, `
+		update calendar_feeds set token = ?, created_on = ?, last_fetched_on = null
+		where player_id = ? and team_id is ?`,
+		token, now, playerID, team,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		_, err = tx.ExecContext(ctx,
+			"insert into calendar_feeds (player_id, team_id, token, created_on) values (?, ?, ?, ?)",
+			playerID, team, token, now,
+		)
+		if err != nil {
+			return nil, FormatError(err)
+		}
+	}
+
+	feeds, err := findCalendarFeeds(ctx, tx, "f.token = ?", token)
+	if err != nil {
+		return nil, err
+	}
+	return feeds[0], tx.Commit()
+}
+
+func (s *CalendarFeedService) FindCalendarFeedByToken(ctx context.Context, token string) (*teamvite.CalendarFeed, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	feeds, err := findCalendarFeeds(ctx, tx, "f.token = ?", token)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" || len(feeds) == 0 {
+		return nil, teamvite.Errorf(teamvite.ENOTFOUND, "Calendar not found.")
+	}
+
+	feed := feeds[0]
+	now := time.Now().UTC()
+	feed.LastFetchedOn = &now
+	_, err = tx.ExecContext(ctx, "update calendar_feeds set last_fetched_on = ? where id = ?", now, feed.ID)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	return feed, tx.Commit()
+}
+
+func (s *CalendarFeedService) FindCalendarFeeds(ctx context.Context) ([]*teamvite.CalendarFeed, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return findCalendarFeeds(ctx, tx, "f.player_id = ?", teamvite.UserIDFromContext(ctx))
+}
+
+func (s *CalendarFeedService) UpdateCalendarFeed(ctx context.Context, id uint64, hideDeclined bool) (*teamvite.CalendarFeed, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	playerID := teamvite.UserIDFromContext(ctx)
+	_, err = tx.ExecContext(ctx,
+		"update calendar_feeds set hide_declined = ? where id = ? and player_id = ?",
+		hideDeclined, id, playerID)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+
+	feeds, err := findCalendarFeeds(ctx, tx, "f.id = ? and f.player_id = ?", id, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(feeds) == 0 {
+		return nil, teamvite.Errorf(teamvite.ENOTFOUND, "Calendar not found.")
+	}
+	return feeds[0], tx.Commit()
+}
+
+func (s *CalendarFeedService) DeleteCalendarFeed(ctx context.Context, id uint64) error {
+	result, err := s.db.ExecContext(ctx,
+		"delete from calendar_feeds where id = ? and player_id = ?",
+		id, teamvite.UserIDFromContext(ctx))
+	if err != nil {
+		return FormatError(err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return teamvite.Errorf(teamvite.ENOTFOUND, "Calendar not found.")
+	}
+	return nil
+}
+
+func findCalendarFeeds(ctx context.Context, tx *sql.Tx, where string, args ...interface{}) ([]*teamvite.CalendarFeed, error) {
+	rows, err := tx.QueryContext(ctx, `
+		select f.id, f.player_id, coalesce(f.team_id, 0), coalesce(t.name, ''), f.hide_declined, f.token, f.created_on, f.last_fetched_on
+		from calendar_feeds f
+		left join teams t on f.team_id = t.id
+		where `+where+`
+		order by t.name`,
+		args...)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	feeds := make([]*teamvite.CalendarFeed, 0)
+	for rows.Next() {
+		var f teamvite.CalendarFeed
+		var lastFetched sql.NullTime
+		if err := rows.Scan(&f.ID, &f.PlayerID, &f.TeamID, &f.TeamName, &f.HideDeclined, &f.Token, &f.CreatedOn, &lastFetched); err != nil {
+			return nil, err
+		}
+		if lastFetched.Valid {
+			f.LastFetchedOn = &lastFetched.Time
+		}
+		feeds = append(feeds, &f)
+	}
+	return feeds, rows.Err()
+}
@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benprew/teamvite"
+)
+
+func TestCalendarFeed(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into divisions (id, name) values (1, 'm1');
+		insert into teams (id, name, division_id) values (1, 'Blue', 1);
+		insert into players (id, name, email) values (1, 'Player', 'p@example.com'), (2, 'Outsider', 'o@example.com');
+		insert into players_teams (player_id, team_id, is_manager) values (1, 1, false);`)
+	panicIf(err)
+
+	fs := NewCalendarFeedService(db)
+	ctx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 1})
+	outsiderCtx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 2})
+
+	if _, err := fs.CreateCalendarFeed(outsiderCtx, 1); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+		t.Errorf("feed of another team: err = %v; want unauthorized", err)
+	}
+
+	feed, err := fs.CreateCalendarFeed(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := fs.FindCalendarFeedByToken(context.Background(), feed.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.PlayerID != 1 || found.TeamID != 1 || found.LastFetchedOn == nil {
+		t.Errorf("found feed = %+v", found)
+	}
+
+	// rotating replaces the token
+	rotated, err := fs.CreateCalendarFeed(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.ID != feed.ID || rotated.Token == feed.Token {
+		t.Errorf("rotated feed = %+v; want new token for feed %d", rotated, feed.ID)
+	}
+	if _, err := fs.FindCalendarFeedByToken(context.Background(), feed.Token); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("old token: err = %v; want not found", err)
+	}
+
+	if err := fs.DeleteCalendarFeed(outsiderCtx, rotated.ID); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("revoke another player's feed: err = %v; want not found", err)
+	}
+	if err := fs.DeleteCalendarFeed(ctx, rotated.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.FindCalendarFeedByToken(context.Background(), rotated.Token); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("revoked token: err = %v; want not found", err)
+	}
+}
+
+func TestPersonalCalendarFeed(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into divisions (id, name) values (1, 'm1');
+		insert into teams (id, name, division_id) values (1, 'Blue', 1);
+		insert into players (id, name, email) values (1, 'Player', 'p@example.com');
+		insert into players_teams (player_id, team_id, is_manager) values (1, 1, false);
+		insert into games (id, team_id, season_id, time) values (1, 1, 1, 0), (2, 1, 1, 1);
+		insert into players_games (player_id, game_id, status) values (1, 1, 'N'), (1, 2, '?');`)
+	panicIf(err)
+
+	fs := NewCalendarFeedService(db)
+	ctx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 1})
+
+	feed, err := fs.CreateCalendarFeed(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := fs.CreateCalendarFeed(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.ID != feed.ID || rotated.TeamID != 0 {
+		t.Errorf("rotated feed = %+v; want personal feed %d", rotated, feed.ID)
+	}
+	// a player has one personal feed, even though its team is null
+	_, err = db.Exec("insert into calendar_feeds (player_id, token, created_on) values (1, 'dup', 0)")
+	if err == nil {
+		t.Errorf("created a second personal feed")
+	}
+	updated, err := fs.UpdateCalendarFeed(ctx, feed.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.HideDeclined {
+		t.Errorf("feed doesn't hide declined games")
+	}
+
+	// unanswered reminders aren't replies
+	statuses, err := NewGameService(db).PlayerStatuses(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 1 || statuses[1] != "N" {
+		t.Errorf("statuses = %v; want game 1 declined", statuses)
+	}
+}
@@ -0,0 +1,62 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/benprew/teamvite"
+)
+
+// tables with rows that are useless once they expire
+var expiringTables = []string{
+	"sessions",
+	"signups",
+	"password_resets",
+	"action_tokens",
+	"passkey_challenges",
+}
+
+type CleanupService struct {
+	db *sql.DB
+}
+
+// Ensure service implements interface.
+var _ teamvite.CleanupService = (*CleanupService)(nil)
+
+// NewCleanupService returns a new instance of CleanupService.
+func NewCleanupService(db *sql.DB) *CleanupService {
+	return &CleanupService{db: db}
+}
+
+func (s *CleanupService) PurgeExpired(ctx context.Context) (teamvite.CleanupReport, error) {
+	report := teamvite.CleanupReport{}
+	for _, table := range expiringTables {
+		n, err := s.purgeTable(ctx, table)
+		if err != nil {
+			return report, err
+		}
+		report[table] = n
+	}
+	return report, nil
+}
+
+// purgeTable deletes the table's expired rows. expires_on is stored in unix
+// seconds, a row with anything else can't be compared so it's deleted too.
+func (s *CleanupService) purgeTable(ctx context.Context, table string) (int64, error) {
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM "+table+" WHERE expires_on < ? OR typeof(expires_on) != 'integer'",
+		time.Now().Unix())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (s *CleanupService) Vacuum(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, "VACUUM"); err != nil {
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, "PRAGMA optimize")
+	return err
+}
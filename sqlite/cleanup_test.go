@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPurgeExpired(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	for _, expiresOn := range []time.Time{past, future} {
+		_, err := db.Exec("insert into sessions (id, player_id, ip, expires_on) values (?, 1, '127.0.0.1', ?)", expiresOn.String(), expiresOn.Unix())
+		panicIf(err)
+		_, err = db.Exec("insert into signups (token, name, email, password, expires_on) values (?, '', '', '', ?)", expiresOn.String(), expiresOn.Unix())
+		panicIf(err)
+		_, err = db.Exec("insert into password_resets (token, player_id, expires_on) values (?, 1, ?)", expiresOn.String(), expiresOn.Unix())
+		panicIf(err)
+		_, err = db.Exec("insert into action_tokens (token, player_id, game_id, action, expires_on) values (?, 1, 1, 'rsvp', ?)", expiresOn.String(), expiresOn.Unix())
+		panicIf(err)
+		_, err = db.Exec("insert into passkey_challenges (challenge, expires_on) values (?, ?)", expiresOn.String(), expiresOn.Unix())
+		panicIf(err)
+	}
+	// sessions from before expires_on was set
+	_, err := db.Exec("insert into sessions (id, player_id) values ('default', 1)")
+	panicIf(err)
+	// expiry times that can't be compared would otherwise never be purged
+	_, err = db.Exec("insert into sessions (id, player_id, expires_on) values ('unparsed', 1, 'next week')")
+	panicIf(err)
+
+	s := NewCleanupService(db)
+	report, err := s.PurgeExpired(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range expiringTables {
+		purged := int64(1)
+		if table == "sessions" {
+			purged = 2
+		}
+		if report[table] != purged {
+			t.Errorf("purged %d from %s; want %d", report[table], table, purged)
+		}
+		want := 1
+		if table == "sessions" {
+			want = 2
+		}
+		var left int
+		panicIf(db.QueryRow("select count(*) from " + table).Scan(&left))
+		if left != want {
+			t.Errorf("%d rows left in %s; want %d", left, table, want)
+		}
+	}
+	if report.Total() != int64(len(expiringTables)+1) {
+		t.Errorf("total = %d", report.Total())
+	}
+
+	if report, err := s.PurgeExpired(ctx); err != nil || report.Total() != 0 {
+		t.Errorf("second purge = %v, %v", report, err)
+	}
+	if err := s.Vacuum(ctx); err != nil {
+		t.Error(err)
+	}
+}
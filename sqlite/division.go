@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
 
 	"github.com/benprew/teamvite"
 )
@@ -56,6 +57,82 @@ func (s *DivisionService) FindDivisions(ctx context.Context, filter teamvite.Div
 	return Divisions, n, err
 }
 
+func (s *DivisionService) CreateDivision(ctx context.Context, division *teamvite.Division) error {
+	division.Name = strings.TrimSpace(division.Name)
+	if division.Name == "" {
+		return teamvite.Errorf(teamvite.EINVALID, "Division name is required.")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkAdmin(ctx, tx); err != nil {
+		return err
+	}
+
+	division.OrganizationID = ownerOrganizationID(ctx)
+	result, err := tx.ExecContext(ctx,
+		"insert into divisions (organization_id, name) values (?, ?)",
+		division.OrganizationID, division.Name)
+	if err = FormatError(err); teamvite.ErrorCode(err) == teamvite.ECONFLICT {
+		return teamvite.Errorf(teamvite.ECONFLICT, "A division named %s already exists.", division.Name)
+	} else if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	division.ID = uint64(id)
+	division.Archived = false
+	return tx.Commit()
+}
+
+func (s *DivisionService) UpdateDivision(ctx context.Context, id uint64, upd teamvite.DivisionUpdate) (*teamvite.Division, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkAdmin(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	divisions, _, err := findDivisions(ctx, tx, teamvite.DivisionFilter{ID: id})
+	if err != nil {
+		return nil, err
+	}
+	if id == 0 || len(divisions) == 0 {
+		return nil, teamvite.Errorf(teamvite.ENOTFOUND, "Division not found: %v", id)
+	}
+	division := divisions[0]
+	prev := *division
+
+	if v := upd.Name; v != nil {
+		division.Name = strings.TrimSpace(*v)
+	}
+	if v := upd.Archived; v != nil {
+		division.Archived = *v
+	}
+	if division.Name == "" {
+		return &prev, teamvite.Errorf(teamvite.EINVALID, "Division name is required.")
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"update divisions set name = ?, archived = ? where id = ?",
+		division.Name, division.Archived, division.ID)
+	if err = FormatError(err); teamvite.ErrorCode(err) == teamvite.ECONFLICT {
+		return &prev, teamvite.Errorf(teamvite.ECONFLICT, "A division named %s already exists.", division.Name)
+	} else if err != nil {
+		return &prev, err
+	}
+	return division, tx.Commit()
+}
+
 func findDivisions(ctx context.Context, tx *sql.Tx, filter teamvite.DivisionFilter) (_ []*teamvite.Division, n int, err error) {
 	divisions := make([]*teamvite.Division, 0)
 	var query string
@@ -63,11 +140,19 @@ func findDivisions(ctx context.Context, tx *sql.Tx, filter teamvite.DivisionFilt
 
 	query = `
 		select
-			id, name
+			id, organization_id, name, archived
 		from divisions
 		where 1 = 1
 	`
 
+	if orgID := filter.OrganizationID; orgID != 0 {
+		query += " and organization_id = ?"
+		args = append(args, orgID)
+	} else if orgID := teamvite.OrganizationIDFromContext(ctx); orgID != 0 {
+		query += " and organization_id = ?"
+		args = append(args, orgID)
+	}
+
 	if filter.ID != 0 {
 		query += " and id = ?"
 		args = append(args, filter.ID)
@@ -78,6 +163,11 @@ func findDivisions(ctx context.Context, tx *sql.Tx, filter teamvite.DivisionFilt
 		args = append(args, filter.Name)
 	}
 
+	if filter.Archived != nil {
+		query += " and archived = ?"
+		args = append(args, *filter.Archived)
+	}
+
 	query += " order by name"
 
 	rows, err := tx.QueryContext(ctx, query+FormatLimitOffset(filter.Limit, filter.Offset), args...)
@@ -88,7 +178,7 @@ func findDivisions(ctx context.Context, tx *sql.Tx, filter teamvite.DivisionFilt
 
 	for rows.Next() {
 		var d teamvite.Division
-		err := rows.Scan(&d.ID, &d.Name)
+		err := rows.Scan(&d.ID, &d.OrganizationID, &d.Name, &d.Archived)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benprew/teamvite"
+)
+
+func TestUpdateDivision(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into players (id, name, email) values (1, 'Admin', 'a@example.com'), (2, 'Player', 'p@example.com');
+		insert into organization_admins (organization_id, player_id) values (1, 1);`)
+	panicIf(err)
+
+	ds := NewDivisionService(db)
+	adminCtx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 1})
+	playerCtx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 2})
+
+	if err := ds.CreateDivision(playerCtx, &teamvite.Division{Name: "Coed C1"}); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+		t.Errorf("non-admin create: err = %v; want unauthorized", err)
+	}
+	d := &teamvite.Division{Name: "Coed C1"}
+	if err := ds.CreateDivision(adminCtx, d); err != nil {
+		t.Fatal(err)
+	}
+	if err := ds.CreateDivision(adminCtx, &teamvite.Division{Name: "Coed C1"}); teamvite.ErrorCode(err) != teamvite.ECONFLICT {
+		t.Errorf("duplicate name: err = %v; want conflict", err)
+	}
+
+	archived := true
+	if _, err := ds.UpdateDivision(adminCtx, d.ID, teamvite.DivisionUpdate{Archived: &archived}); err != nil {
+		t.Fatal(err)
+	}
+	notArchived := false
+	divisions, _, err := ds.FindDivisions(context.Background(), teamvite.DivisionFilter{Archived: &notArchived})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(divisions) != 0 {
+		t.Errorf("unarchived divisions = %v; want none", divisions)
+	}
+
+	// new teams can't join archived divisions
+	err = NewTeamService(db).CreateTeam(playerCtx, &teamvite.Team{Name: "Blue", DivisionID: d.ID})
+	if teamvite.ErrorCode(err) != teamvite.EINVALID {
+		t.Errorf("team in archived division: err = %v; want invalid", err)
+	}
+}
@@ -62,6 +62,10 @@ func (s *GameService) FindGames(ctx context.Context, filter teamvite.GameFilter)
 // Inserts the given game into the db, returning the newly-inserted game
 func (s *GameService) CreateGame(ctx context.Context, g *teamvite.Game) error {
 	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
 	if g.Time == nil {
 		return fmt.Errorf("game time is required")
@@ -75,6 +79,21 @@ func (s *GameService) CreateGame(ctx context.Context, g *teamvite.Game) error {
 	if g.Time.Before(time.Now().Add(-time.Hour * 24 * 30)) {
 		return fmt.Errorf("game time too far in the past: %v", g.Time)
 	}
+	// the season has to be one of the team's league
+	var closed bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT s.closed FROM seasons s
+		JOIN divisions d ON d.organization_id = s.organization_id
+		JOIN teams t ON t.division_id = d.id
+		WHERE s.id = ? AND t.id = ?`, g.SeasonID, g.TeamID).Scan(&closed)
+	if err == sql.ErrNoRows {
+		return teamvite.Errorf(teamvite.EINVALID, "Season not found: %v", g.SeasonID)
+	} else if err != nil {
+		return err
+	}
+	if closed {
+		return teamvite.Errorf(teamvite.EINVALID, "Season %v is closed.", g.SeasonID)
+	}
 	result, err := tx.ExecContext(ctx, `
 			INSERT INTO games (team_id, season_id, time, description)
 			VALUES (?, ?, ?, ?)
@@ -90,7 +109,11 @@ func (s *GameService) CreateGame(ctx context.Context, g *teamvite.Game) error {
 		return err
 	}
 	g.ID = uint64(id)
-	return nil
+
+	if err := applyDefaultStatuses(ctx, tx, "g.id = ?", g.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (s *GameService) UpdateStatus(ctx context.Context, game *teamvite.Game, status string) error {
@@ -116,6 +139,74 @@ func (s *GameService) UpdateStatus(ctx context.Context, game *teamvite.Game, sta
 	return err
 }
 
+func (s *GameService) ApplyDefaultStatuses(ctx context.Context, game *teamvite.Game) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := applyDefaultStatuses(ctx, tx, "g.id = ?", game.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *GameService) ApplyUpcomingDefaultStatuses(ctx context.Context, window time.Duration) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = applyDefaultStatuses(ctx, tx,
+		"g.time BETWEEN datetime('now') AND datetime('now', ?)",
+		fmt.Sprintf("+%d seconds", int(window.Seconds())))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applyDefaultStatuses fills in players_games for players with a default
+// response on the teams of the games matching where. Replies the player has
+// already made win over the default.
+func applyDefaultStatuses(ctx context.Context, tx *sql.Tx, where string, args ...interface{}) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO players_games (player_id, game_id, status)
+		SELECT pt.player_id, g.id, pt.default_status
+		FROM games g
+		JOIN players_teams pt USING(team_id)
+		WHERE `+where+` AND pt.default_status != '' AND pt.roster_status = 'active'
+		ON CONFLICT (player_id, game_id) DO UPDATE SET status = excluded.status
+		WHERE players_games.status IN ('', '?')`,
+		args...,
+	)
+	return FormatError(err)
+}
+
+func (s *GameService) PlayerStatuses(ctx context.Context) (map[uint64]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT game_id, status FROM players_games WHERE player_id = ? AND status NOT IN ('', '?')",
+		teamvite.UserIDFromContext(ctx),
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	statuses := map[uint64]string{}
+	for rows.Next() {
+		var gameID uint64
+		var status string
+		if err := rows.Scan(&gameID, &status); err != nil {
+			return nil, err
+		}
+		statuses[gameID] = status
+	}
+	return statuses, rows.Err()
+}
+
 func (s *GameService) ResponsesForGame(ctx context.Context, game *teamvite.Game) (_ []*teamvite.GameResponse, err error) {
 	//var r []*teamvite.GameResponse
 	type Response int
@@ -145,7 +236,7 @@ func (s *GameService) ResponsesForGame(ctx context.Context, game *teamvite.Game)
 		JOIN players_teams pt USING(team_id)
 		JOIN players p ON pt.player_id = p.id
 		LEFT JOIN players_games pg ON pg.game_id = g.id AND pg.player_id = p.id
-		WHERE g.id = ?
+		WHERE g.id = ? AND pt.roster_status = 'active' -- injured reserve players don't count
 		ORDER BY status desc, name`,
 		game.ID,
 	)
@@ -164,6 +255,84 @@ func (s *GameService) ResponsesForGame(ctx context.Context, game *teamvite.Game)
 	return r, nil
 }
 
+func (s *GameService) Messages(ctx context.Context, game *teamvite.Game) ([]*teamvite.GameMessage, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT m.id, m.game_id, m.player_id, p.name, m.body, m.created_on
+		FROM games_messages m
+		JOIN players p ON m.player_id = p.id
+		WHERE m.game_id = ?
+		ORDER BY m.created_on, m.id`,
+		game.ID,
+	)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	messages := make([]*teamvite.GameMessage, 0)
+	for rows.Next() {
+		var m teamvite.GameMessage
+		if err := rows.Scan(&m.ID, &m.GameID, &m.PlayerID, &m.PlayerName, &m.Body, &m.CreatedOn); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &m)
+	}
+	return messages, rows.Err()
+}
+
+func (s *GameService) CreateMessage(ctx context.Context, msg *teamvite.GameMessage) error {
+	user := teamvite.UserFromContext(ctx)
+	if user == nil {
+		return teamvite.Errorf(teamvite.EUNAUTHORIZED, "Must be logged in to post a message")
+	}
+	msg.Body = strings.TrimSpace(msg.Body)
+	if msg.Body == "" {
+		return teamvite.Errorf(teamvite.EINVALID, "Message is required")
+	}
+	if len(msg.Body) > teamvite.MaxMessageLength {
+		return teamvite.Errorf(teamvite.EINVALID, "Message must be %d characters or less", teamvite.MaxMessageLength)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var onTeam bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT count(*) > 0
+		FROM games g
+		JOIN players_teams pt USING(team_id)
+		WHERE g.id = ? AND pt.player_id = ?`,
+		msg.GameID, user.ID,
+	).Scan(&onTeam)
+	if err != nil {
+		return FormatError(err)
+	}
+	if !onTeam {
+		return teamvite.Errorf(teamvite.EUNAUTHORIZED, "Only players on the team can post messages")
+	}
+
+	msg.PlayerID = user.ID
+	msg.PlayerName = user.Name
+	msg.CreatedOn = time.Now()
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO games_messages (game_id, player_id, body, created_on)
+		VALUES (?, ?, ?, ?)`,
+		msg.GameID, msg.PlayerID, msg.Body, msg.CreatedOn,
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	msg.ID = uint64(id)
+	return tx.Commit()
+}
+
 func findGames(ctx context.Context, tx *sql.Tx, filter teamvite.GameFilter) (_ []*teamvite.Game, n int, err error) {
 	// Build WHERE clause. Each part of the WHERE clause is AND-ed together.
 	// Values are appended to an arg list to avoid SQL injection.
@@ -172,6 +341,13 @@ func findGames(ctx context.Context, tx *sql.Tx, filter teamvite.GameFilter) (_ [
 		where, args = append(where, "id = ?"), append(args, v)
 	}
 
+	// a team's or player's games are found from any organization, players
+	// can be on teams in several
+	if orgID := teamvite.OrganizationIDFromContext(ctx); orgID != 0 && filter.ID == 0 && filter.TeamID == 0 && filter.PlayerID == 0 {
+		where = append(where, "team_id IN (SELECT t.id FROM teams t JOIN divisions d ON t.division_id = d.id WHERE d.organization_id = ?)")
+		args = append(args, orgID)
+	}
+
 	if v := filter.TeamID; v != 0 {
 		where, args = append(where, "team_id = ?"), append(args, v)
 	}
@@ -186,6 +362,20 @@ func findGames(ctx context.Context, tx *sql.Tx, filter teamvite.GameFilter) (_ [
 		args = append(args, v)
 	}
 
+	if v := filter.DivisionID; v != 0 {
+		where = append(where, "team_id IN (SELECT id FROM teams WHERE division_id = ?)")
+		args = append(args, v)
+	}
+
+	if v := filter.SeasonID; v != 0 {
+		where = append(where, "season_id = ?")
+		args = append(args, v)
+	}
+
+	if filter.OpenSeasons {
+		where = append(where, "season_id IN (SELECT id FROM seasons WHERE NOT closed)")
+	}
+
 	if v := filter.Time; v != 0 {
 		where = append(where, "time >= ?")
 		args = append(args, v)
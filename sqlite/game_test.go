@@ -0,0 +1,136 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/benprew/teamvite"
+)
+
+// openTestDB returns an in-memory database with the schema from db/create.sql
+func openTestDB(t *testing.T) *sql.DB {
+	db := Open(":memory:")
+	db.SetMaxOpenConns(1)
+	schema, err := os.ReadFile("../db/create.sql")
+	panicIf(err)
+	_, err = db.Exec(string(schema))
+	panicIf(err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestApplyDefaultStatuses(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into divisions (id, name) values (1, 'm1');
+		insert into seasons (id, name) values (1, '2026-fall');
+		insert into teams (id, name, division_id) values (1, 'Team', 1);
+		insert into players (id, name, email) values (1, 'Always', 'a@example.com'), (2, 'Never', 'n@example.com'), (3, 'Asks', 'q@example.com');
+		insert into players_teams (player_id, team_id, default_status) values (1, 1, 'Y'), (2, 1, 'N'), (3, 1, '');`)
+	panicIf(err)
+
+	gs := NewGameService(db)
+	gameTime := time.Now().Add(time.Hour * 24)
+	g := teamvite.Game{TeamID: 1, SeasonID: 1, Time: &gameTime}
+	if err := gs.CreateGame(context.Background(), &g); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := map[uint64]string{}
+	rows, err := db.Query("select player_id, status from players_games where game_id = ?", g.ID)
+	panicIf(err)
+	for rows.Next() {
+		var id uint64
+		var status string
+		panicIf(rows.Scan(&id, &status))
+		statuses[id] = status
+	}
+	rows.Close()
+
+	want := map[uint64]string{1: "Y", 2: "N"}
+	if len(statuses) != len(want) || statuses[1] != "Y" || statuses[2] != "N" {
+		t.Errorf("statuses = %v; want %v", statuses, want)
+	}
+
+	// a reply made by the player is kept
+	_, err = db.Exec("update players_games set status = 'N' where player_id = 1")
+	panicIf(err)
+	if err := gs.ApplyDefaultStatuses(context.Background(), &g); err != nil {
+		t.Fatal(err)
+	}
+	var status string
+	panicIf(db.QueryRow("select status from players_games where player_id = 1 and game_id = ?", g.ID).Scan(&status))
+	if status != "N" {
+		t.Errorf("status = %s; want N", status)
+	}
+}
+
+func TestApplyUpcomingDefaultStatuses(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into divisions (id, name) values (1, 'm1');
+		insert into seasons (id, name) values (1, '2026-fall');
+		insert into teams (id, name, division_id) values (1, 'Team', 1);
+		insert into players (id, name, email) values (1, 'Always', 'a@example.com');
+		insert into players_teams (player_id, team_id, default_status) values (1, 1, 'Y');
+		insert into games (id, team_id, season_id, time) values
+			(1, 1, 1, datetime('now', '+1 day')), (2, 1, 1, datetime('now', '+10 days')), (3, 1, 1, datetime('now', '-1 day'));`)
+	panicIf(err)
+
+	if err := NewGameService(db).ApplyUpcomingDefaultStatuses(context.Background(), time.Hour*24*5); err != nil {
+		t.Fatal(err)
+	}
+	var gameIDs []uint64
+	rows, err := db.Query("select game_id from players_games where player_id = 1 and status = 'Y'")
+	panicIf(err)
+	for rows.Next() {
+		var id uint64
+		panicIf(rows.Scan(&id))
+		gameIDs = append(gameIDs, id)
+	}
+	rows.Close()
+	if len(gameIDs) != 1 || gameIDs[0] != 1 {
+		t.Errorf("games with default status = %v; want [1]", gameIDs)
+	}
+}
+
+func TestCreateMessage(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into divisions (id, name) values (1, 'm1');
+		insert into teams (id, name, division_id) values (1, 'Blue', 1), (2, 'Red', 1);
+		insert into players (id, name, email) values (1, 'Teammate', 't@example.com'), (2, 'Outsider', 'o@example.com');
+		insert into players_teams (player_id, team_id) values (1, 1), (2, 2);
+		insert into seasons (id, name) values (1, '2026-fall');
+		insert into games (id, team_id, season_id, time) values (1, 1, 1, datetime('now', '+1 day'));`)
+	panicIf(err)
+
+	gs := NewGameService(db)
+	teammateCtx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 1, Name: "Teammate"})
+	outsiderCtx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 2, Name: "Outsider"})
+
+	if err := gs.CreateMessage(context.Background(), &teamvite.GameMessage{GameID: 1, Body: "hi"}); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+		t.Errorf("anonymous message: err = %v; want unauthorized", err)
+	}
+	if err := gs.CreateMessage(outsiderCtx, &teamvite.GameMessage{GameID: 1, Body: "hi"}); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+		t.Errorf("non-member message: err = %v; want unauthorized", err)
+	}
+	if err := gs.CreateMessage(teammateCtx, &teamvite.GameMessage{GameID: 1, Body: "  "}); teamvite.ErrorCode(err) != teamvite.EINVALID {
+		t.Errorf("blank message: err = %v; want invalid", err)
+	}
+
+	msg := teamvite.GameMessage{GameID: 1, Body: " Running late "}
+	if err := gs.CreateMessage(teammateCtx, &msg); err != nil {
+		t.Fatal(err)
+	}
+	messages, err := gs.Messages(context.Background(), &teamvite.Game{ID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || messages[0].PlayerID != 1 || messages[0].Body != "Running late" {
+		t.Errorf("messages = %v", messages)
+	}
+}
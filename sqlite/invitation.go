@@ -0,0 +1,320 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/benprew/teamvite"
+	"github.com/dchest/uniuri"
+)
+
+type InvitationService struct {
+	db *sql.DB
+}
+
+// Ensure service implements interface.
+var _ teamvite.InvitationService = (*InvitationService)(nil)
+
+// NewInvitationService returns a new instance of InvitationService.
+func NewInvitationService(db *sql.DB) *InvitationService {
+	return &InvitationService{db: db}
+}
+
+func (s *InvitationService) CreateInvitation(ctx context.Context, inv *teamvite.Invitation) error {
+	inv.Email = strings.TrimSpace(inv.Email)
+	inv.Name = strings.TrimSpace(inv.Name)
+	if inv.Email == "" {
+		return teamvite.Errorf(teamvite.EINVALID, "Email is required.")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkInviter(ctx, tx, inv.TeamID); err != nil {
+		return err
+	}
+
+	var onTeam, invited bool
+	err = tx.QueryRowContext(ctx, `
+		select
+			exists (select 1 from players_teams pt join players p on pt.player_id = p.id
+				where pt.team_id = ? and p.email = ?),
+			exists (select 1 from invitations
+				where team_id = ? and email = ? and status = 'pending' and expires_on >= ?)`,
+		inv.TeamID, inv.Email, inv.TeamID, inv.Email, time.Now().UTC(),
+	).Scan(&onTeam, &invited)
+	if err != nil {
+		return err
+	}
+	if onTeam {
+		return teamvite.Errorf(teamvite.ECONFLICT, "%s is already on the team.", inv.Email)
+	}
+	if invited {
+		return teamvite.Errorf(teamvite.ECONFLICT, "%s has already been invited, resend the invitation instead.", inv.Email)
+	}
+
+	inv.InvitedByID = teamvite.UserIDFromContext(ctx)
+	inv.Token = genToken()
+	inv.Status = teamvite.InvitationPending
+	inv.CreatedOn = time.Now().UTC()
+	inv.ExpiresOn = inv.CreatedOn.Add(teamvite.InvitationLength)
+	result, err := tx.ExecContext(ctx, `
+		insert into invitations (team_id, email, name, phone, is_manager, token, invited_by, status, created_on, expires_on)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		inv.TeamID, inv.Email, inv.Name, inv.Phone, inv.IsManager, inv.Token, inv.InvitedByID, inv.Status, inv.CreatedOn, inv.ExpiresOn,
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	inv.ID = uint64(id)
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// fill in team and inviter names for the invitation email
+	invs, err := s.FindInvitations(ctx, teamvite.InvitationFilter{ID: inv.ID})
+	if err != nil {
+		return err
+	}
+	*inv = *invs[0]
+	return nil
+}
+
+func (s *InvitationService) FindInvitationByToken(ctx context.Context, token string) (*teamvite.Invitation, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return findPendingInvitation(ctx, tx, token)
+}
+
+func (s *InvitationService) FindInvitations(ctx context.Context, filter teamvite.InvitationFilter) ([]*teamvite.Invitation, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return findInvitations(ctx, tx, filter)
+}
+
+func (s *InvitationService) AcceptInvitation(ctx context.Context, token string) (*teamvite.Invitation, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	inv, err := findPendingInvitation(ctx, tx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	players, _, err := findPlayers(ctx, tx, teamvite.PlayerFilter{Email: inv.Email, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	var playerID uint64
+	if len(players) > 0 {
+		playerID = players[0].ID
+	} else {
+		result, err := tx.ExecContext(ctx,
+			"insert into players (name, email, phone) values (?, ?, ?)",
+			inv.Name, inv.Email, inv.Phone)
+		if err != nil {
+			return nil, FormatError(err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		playerID = uint64(id)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		insert into players_teams (player_id, team_id, is_manager) values (?, ?, ?)
+		on conflict (team_id, player_id) do nothing`,
+		playerID, inv.TeamID, inv.IsManager)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	if err := addToSeason(ctx, tx, inv.TeamID, playerID); err != nil {
+		return nil, err
+	}
+
+	if err := setInvitationStatus(ctx, tx, inv, teamvite.InvitationAccepted); err != nil {
+		return nil, err
+	}
+	return inv, tx.Commit()
+}
+
+func (s *InvitationService) DeclineInvitation(ctx context.Context, token string) (*teamvite.Invitation, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	inv, err := findPendingInvitation(ctx, tx, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := setInvitationStatus(ctx, tx, inv, teamvite.InvitationDeclined); err != nil {
+		return nil, err
+	}
+	return inv, tx.Commit()
+}
+
+func (s *InvitationService) RevokeInvitation(ctx context.Context, teamID, id uint64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkInviter(ctx, tx, teamID); err != nil {
+		return err
+	}
+	inv, err := findTeamInvitation(ctx, tx, teamID, id)
+	if err != nil {
+		return err
+	}
+	if err := setInvitationStatus(ctx, tx, inv, teamvite.InvitationRevoked); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *InvitationService) RenewInvitation(ctx context.Context, teamID, id uint64) (*teamvite.Invitation, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkInviter(ctx, tx, teamID); err != nil {
+		return nil, err
+	}
+	inv, err := findTeamInvitation(ctx, tx, teamID, id)
+	if err != nil {
+		return nil, err
+	}
+	if inv.Status != teamvite.InvitationPending {
+		return nil, teamvite.Errorf(teamvite.EINVALID, "Only pending invitations can be resent.")
+	}
+
+	inv.Token = genToken()
+	inv.ExpiresOn = time.Now().UTC().Add(teamvite.InvitationLength)
+	_, err = tx.ExecContext(ctx,
+		"update invitations set token = ?, expires_on = ? where id = ?",
+		inv.Token, inv.ExpiresOn, inv.ID)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	return inv, tx.Commit()
+}
+
+// checkInviter returns EUNAUTHORIZED unless the user manages the team.
+func checkInviter(ctx context.Context, tx *sql.Tx, teamID uint64) error {
+	isMgr, err := isManager(ctx, tx, teamvite.UserIDFromContext(ctx), teamID)
+	if err != nil {
+		return err
+	}
+	if !isMgr {
+		return teamvite.Errorf(teamvite.EUNAUTHORIZED, "Only managers can invite players to the team.")
+	}
+	return nil
+}
+
+func findPendingInvitation(ctx context.Context, tx *sql.Tx, token string) (*teamvite.Invitation, error) {
+	invs, err := findInvitations(ctx, tx, teamvite.InvitationFilter{Token: token})
+	if err != nil {
+		return nil, err
+	}
+	if token == "" || len(invs) == 0 || invs[0].Status != teamvite.InvitationPending || invs[0].Expired() {
+		return nil, teamvite.Errorf(teamvite.ENOTFOUND, "This invitation has expired or is no longer valid.")
+	}
+	return invs[0], nil
+}
+
+func findTeamInvitation(ctx context.Context, tx *sql.Tx, teamID, id uint64) (*teamvite.Invitation, error) {
+	invs, err := findInvitations(ctx, tx, teamvite.InvitationFilter{ID: id, TeamID: teamID})
+	if err != nil {
+		return nil, err
+	}
+	if id == 0 || len(invs) == 0 {
+		return nil, teamvite.Errorf(teamvite.ENOTFOUND, "invitation not found: %v", id)
+	}
+	return invs[0], nil
+}
+
+func setInvitationStatus(ctx context.Context, tx *sql.Tx, inv *teamvite.Invitation, status string) error {
+	inv.Status = status
+	_, err := tx.ExecContext(ctx, "update invitations set status = ? where id = ?", status, inv.ID)
+	return FormatError(err)
+}
+
+func findInvitations(ctx context.Context, tx *sql.Tx, filter teamvite.InvitationFilter) ([]*teamvite.Invitation, error) {
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := filter.ID; v != 0 {
+		where, args = append(where, "i.id = ?"), append(args, v)
+	}
+	if v := filter.TeamID; v != 0 {
+		where, args = append(where, "i.team_id = ?"), append(args, v)
+	}
+	if v := filter.Token; v != "" {
+		where, args = append(where, "i.token = ?"), append(args, v)
+	}
+	if len(filter.Statuses) > 0 {
+		where = append(where, "i.status in ("+strings.TrimSuffix(strings.Repeat("?,", len(filter.Statuses)), ",")+")")
+		for _, status := range filter.Statuses {
+			args = append(args, status)
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		select
+			i.id, i.team_id, t.name, i.email, i.name, i.phone, i.is_manager, i.token, i.invited_by, coalesce(p.name, ''),
+			i.status, i.created_on, i.expires_on
+		from invitations i
+		join teams t on i.team_id = t.id
+		left join players p on i.invited_by = p.id
+		where `+strings.Join(where, " and ")+`
+		order by i.created_on desc`,
+		args...)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	invs := make([]*teamvite.Invitation, 0)
+	for rows.Next() {
+		var i teamvite.Invitation
+		if err := rows.Scan(
+			&i.ID, &i.TeamID, &i.TeamName, &i.Email, &i.Name, &i.Phone, &i.IsManager, &i.Token, &i.InvitedByID, &i.InvitedByName,
+			&i.Status, &i.CreatedOn, &i.ExpiresOn,
+		); err != nil {
+			return nil, err
+		}
+		invs = append(invs, &i)
+	}
+	return invs, rows.Err()
+}
+
+// genToken returns a random token for links sent by email. Tokens have the
+// same ~148 bits of entropy as session IDs.
+func genToken() string {
+	return uniuri.NewLen(25)
+}
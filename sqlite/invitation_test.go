@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benprew/teamvite"
+)
+
+func TestInvitationManagersOnly(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into divisions (id, name) values (1, 'm1');
+		insert into teams (id, name, division_id) values (1, 'Blue', 1), (2, 'Red', 1);
+		insert into players (id, name, email) values (1, 'Manager', 'm@example.com'), (2, 'Player', 'p@example.com'), (3, 'Outsider', 'o@example.com');
+		insert into players_teams (player_id, team_id, is_manager) values (1, 1, true), (2, 1, false), (3, 2, true);`)
+	panicIf(err)
+
+	is := NewInvitationService(db)
+	ctx := func(id uint64) context.Context {
+		return teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: id})
+	}
+
+	for name, userID := range map[string]uint64{"non-manager": 2, "non-member": 3} {
+		inv := teamvite.Invitation{TeamID: 1, Email: "new@example.com"}
+		if err := is.CreateInvitation(ctx(userID), &inv); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+			t.Errorf("%s invite: err = %v; want unauthorized", name, err)
+		}
+	}
+
+	inv := teamvite.Invitation{TeamID: 1, Email: "new@example.com", Name: "New"}
+	if err := is.CreateInvitation(ctx(1), &inv); err != nil {
+		t.Fatal(err)
+	}
+	if inv.InvitedByID != 1 || inv.Status != teamvite.InvitationPending {
+		t.Errorf("invitation = %v", inv)
+	}
+
+	for name, userID := range map[string]uint64{"non-manager": 2, "non-member": 3} {
+		if _, err := is.RenewInvitation(ctx(userID), 1, inv.ID); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+			t.Errorf("%s resend: err = %v; want unauthorized", name, err)
+		}
+		if err := is.RevokeInvitation(ctx(userID), 1, inv.ID); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+			t.Errorf("%s revoke: err = %v; want unauthorized", name, err)
+		}
+	}
+
+	if err := is.RevokeInvitation(ctx(1), 1, inv.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := is.AcceptInvitation(context.Background(), inv.Token); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("accepting revoked invitation: err = %v; want not found", err)
+	}
+}
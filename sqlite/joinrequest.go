@@ -0,0 +1,192 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/benprew/teamvite"
+)
+
+type JoinRequestService struct {
+	db *sql.DB
+}
+
+// Ensure service implements interface.
+var _ teamvite.JoinRequestService = (*JoinRequestService)(nil)
+
+// NewJoinRequestService returns a new instance of JoinRequestService.
+func NewJoinRequestService(db *sql.DB) *JoinRequestService {
+	return &JoinRequestService{db: db}
+}
+
+func (s *JoinRequestService) CreateJoinRequest(ctx context.Context, req *teamvite.JoinRequest) error {
+	req.PlayerID = teamvite.UserIDFromContext(ctx)
+	req.Message = strings.TrimSpace(req.Message)
+	if req.PlayerID == 0 {
+		return teamvite.Errorf(teamvite.EUNAUTHORIZED, "You must be logged in to join a team.")
+	}
+	if len(req.Message) > teamvite.MaxJoinRequestMessageLength {
+		return teamvite.Errorf(teamvite.EINVALID, "Message must be %d characters or less.", teamvite.MaxJoinRequestMessageLength)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var onTeam, requested bool
+	err = tx.QueryRowContext(ctx, `
+		select
+			exists (select 1 from players_teams where team_id = ? and player_id = ?),
+			exists (select 1 from join_requests where team_id = ? and player_id = ? and status = 'pending')`,
+		req.TeamID, req.PlayerID, req.TeamID, req.PlayerID,
+	).Scan(&onTeam, &requested)
+	if err != nil {
+		return err
+	}
+	if onTeam {
+		return teamvite.Errorf(teamvite.ECONFLICT, "You are already on the team.")
+	}
+	if requested {
+		return teamvite.Errorf(teamvite.ECONFLICT, "You have already asked to join, a manager will respond soon.")
+	}
+
+	req.Status = teamvite.JoinRequestPending
+	req.CreatedOn = time.Now().UTC()
+	result, err := tx.ExecContext(ctx, `
+		insert into join_requests (team_id, player_id, message, status, created_on)
+		values (?, ?, ?, ?, ?)`,
+		req.TeamID, req.PlayerID, req.Message, req.Status, req.CreatedOn,
+	)
+	if err != nil {
+		return FormatError(err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	reqs, err := findJoinRequests(ctx, tx, teamvite.JoinRequestFilter{ID: uint64(id)})
+	if err != nil {
+		return err
+	}
+	*req = *reqs[0]
+
+	rows, err := tx.QueryContext(ctx, `
+		select p.email from players_teams pt join players p on pt.player_id = p.id
+		where pt.team_id = ? and pt.is_manager and p.email != ''`,
+		req.TeamID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return err
+		}
+		req.ManagerEmails = append(req.ManagerEmails, email)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *JoinRequestService) FindJoinRequests(ctx context.Context, filter teamvite.JoinRequestFilter) ([]*teamvite.JoinRequest, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return findJoinRequests(ctx, tx, filter)
+}
+
+func (s *JoinRequestService) UpdateJoinRequest(ctx context.Context, teamID, id uint64, status string) (*teamvite.JoinRequest, error) {
+	if status != teamvite.JoinRequestApproved && status != teamvite.JoinRequestRejected {
+		return nil, teamvite.Errorf(teamvite.EINVALID, "invalid join request status: %s", status)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	pending := teamvite.JoinRequestPending
+	reqs, err := findJoinRequests(ctx, tx, teamvite.JoinRequestFilter{ID: id, TeamID: teamID, Status: &pending})
+	if err != nil {
+		return nil, err
+	}
+	if id == 0 || len(reqs) == 0 {
+		return nil, teamvite.Errorf(teamvite.ENOTFOUND, "No pending join request found.")
+	}
+
+	req := reqs[0]
+	req.Status = status
+	_, err = tx.ExecContext(ctx, "update join_requests set status = ? where id = ?", status, req.ID)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	if status == teamvite.JoinRequestApproved {
+		// they may have joined another way since asking
+		_, err = tx.ExecContext(ctx, `
+			insert into players_teams (player_id, team_id) values (?, ?)
+			on conflict (team_id, player_id) do nothing`,
+			req.PlayerID, req.TeamID)
+		if err != nil {
+			return nil, FormatError(err)
+		}
+		if err := addToSeason(ctx, tx, req.TeamID, req.PlayerID); err != nil {
+			return nil, err
+		}
+	}
+	return req, tx.Commit()
+}
+
+func findJoinRequests(ctx context.Context, tx *sql.Tx, filter teamvite.JoinRequestFilter) ([]*teamvite.JoinRequest, error) {
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := filter.ID; v != 0 {
+		where, args = append(where, "j.id = ?"), append(args, v)
+	}
+	if v := filter.TeamID; v != 0 {
+		where, args = append(where, "j.team_id = ?"), append(args, v)
+	}
+	if v := filter.PlayerID; v != 0 {
+		where, args = append(where, "j.player_id = ?"), append(args, v)
+	}
+	if v := filter.Status; v != nil {
+		where, args = append(where, "j.status = ?"), append(args, *v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		select
+			j.id, j.team_id, t.name, j.player_id, p.name, p.email, j.message, j.status, j.created_on
+		from join_requests j
+		join teams t on j.team_id = t.id
+		join players p on j.player_id = p.id
+		where `+strings.Join(where, " and ")+`
+		order by j.created_on`,
+		args...)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	reqs := make([]*teamvite.JoinRequest, 0)
+	for rows.Next() {
+		var j teamvite.JoinRequest
+		if err := rows.Scan(
+			&j.ID, &j.TeamID, &j.TeamName, &j.PlayerID, &j.PlayerName, &j.PlayerEmail,
+			&j.Message, &j.Status, &j.CreatedOn,
+		); err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, &j)
+	}
+	return reqs, rows.Err()
+}
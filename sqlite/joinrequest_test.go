@@ -0,0 +1,60 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benprew/teamvite"
+)
+
+func TestUpdateJoinRequest(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into divisions (id, name) values (1, 'm1');
+		insert into seasons (id, name) values (1, '2026-fall');
+		insert into teams (id, name, division_id, season_id) values (1, 'Blue', 1, 1);
+		insert into players (id, name, email) values (1, 'Manager', 'm@example.com'), (2, 'Approved', 'a@example.com'), (3, 'Rejected', 'r@example.com');
+		insert into players_teams (player_id, team_id, is_manager) values (1, 1, true);`)
+	panicIf(err)
+
+	js := NewJoinRequestService(db)
+	ctx := func(id uint64) context.Context {
+		return teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: id})
+	}
+	approved := teamvite.JoinRequest{TeamID: 1}
+	panicIf(js.CreateJoinRequest(ctx(2), &approved))
+	rejected := teamvite.JoinRequest{TeamID: 1}
+	panicIf(js.CreateJoinRequest(ctx(3), &rejected))
+
+	if _, err := js.UpdateJoinRequest(ctx(1), 1, approved.ID, teamvite.JoinRequestApproved); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := js.UpdateJoinRequest(ctx(1), 1, rejected.ID, teamvite.JoinRequestRejected); err != nil {
+		t.Fatal(err)
+	}
+	// a request can only be answered once
+	if _, err := js.UpdateJoinRequest(ctx(1), 1, approved.ID, teamvite.JoinRequestApproved); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("approving twice: err = %v; want not found", err)
+	}
+
+	rostered := map[uint64]bool{}
+	rows, err := db.Query(`
+		select pt.player_id, pts.status is not null
+		from players_teams pt
+		left join players_teams_seasons pts on pts.player_id = pt.player_id and pts.team_id = pt.team_id and pts.season_id = 1
+		where pt.team_id = 1`)
+	panicIf(err)
+	for rows.Next() {
+		var id uint64
+		var inSeason bool
+		panicIf(rows.Scan(&id, &inSeason))
+		rostered[id] = inSeason
+	}
+	rows.Close()
+	if inSeason, ok := rostered[2]; !ok || !inSeason {
+		t.Errorf("approved player on team = %t, in season = %t", ok, inSeason)
+	}
+	if _, ok := rostered[3]; ok {
+		t.Errorf("rejected player was added to the team")
+	}
+}
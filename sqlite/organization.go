@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/benprew/teamvite"
+)
+
+type OrganizationService struct {
+	db *sql.DB
+}
+
+// Ensure service implements interface.
+var _ teamvite.OrganizationService = (*OrganizationService)(nil)
+
+// NewOrganizationService returns a new instance of OrganizationService.
+func NewOrganizationService(db *sql.DB) *OrganizationService {
+	return &OrganizationService{db: db}
+}
+
+func (s *OrganizationService) FindOrganizationByID(ctx context.Context, id uint64) (*teamvite.Organization, error) {
+	org, err := findOrganization(ctx, s.db, "id = ?", id)
+	if err == sql.ErrNoRows {
+		return nil, teamvite.Errorf(teamvite.ENOTFOUND, "Organization not found: %v", id)
+	}
+	return org, err
+}
+
+func (s *OrganizationService) FindOrganizationByHost(ctx context.Context, host string) (*teamvite.Organization, error) {
+	org, err := findOrganization(ctx, s.db, "host = ?", host)
+	if err == sql.ErrNoRows {
+		return s.FindOrganizationByID(ctx, teamvite.DefaultOrganizationID)
+	}
+	return org, err
+}
+
+func findOrganization(ctx context.Context, db *sql.DB, where string, arg interface{}) (*teamvite.Organization, error) {
+	var o teamvite.Organization
+	err := db.QueryRowContext(ctx,
+		"select id, name, host, sender, sender_name from organizations where "+where, arg,
+	).Scan(&o.ID, &o.Name, &o.Host, &o.Sender, &o.SenderName)
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
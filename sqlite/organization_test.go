@@ -0,0 +1,99 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benprew/teamvite"
+)
+
+func TestOrganizations(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into organizations (id, name, host, sender) values (2, 'Seattle Indoor', 'seattle.example.com', 'league@seattle.example.com');
+		insert into players (id, name, email) values (1, 'Admin', 'a@example.com'), (2, 'Player', 'p@example.com');
+		insert into organization_admins (organization_id, player_id) values (1, 1);
+		insert into divisions (id, organization_id, name) values (1, 1, 'm1'), (2, 2, 'm2');
+		insert into seasons (id, organization_id, name) values (1, 1, '2026-fall'), (2, 2, '2026-fall');
+		insert into teams (id, name, division_id) values (1, 'Blue', 1), (2, 'Red', 2);
+		insert into players_teams (player_id, team_id) values (2, 1), (2, 2);`)
+	panicIf(err)
+
+	orgs := NewOrganizationService(db)
+	seattle, err := orgs.FindOrganizationByHost(context.Background(), "seattle.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seattle.ID != 2 || seattle.Sender != "league@seattle.example.com" {
+		t.Errorf("seattle = %+v", seattle)
+	}
+	// unknown hosts get the default organization
+	org, err := orgs.FindOrganizationByHost(context.Background(), "localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if org.ID != teamvite.DefaultOrganizationID {
+		t.Errorf("unknown host organization = %d; want %d", org.ID, teamvite.DefaultOrganizationID)
+	}
+
+	ctx := func(orgID, userID uint64) context.Context {
+		ctx := teamvite.NewContextWithOrganization(context.Background(), &teamvite.Organization{ID: orgID})
+		return teamvite.NewContextWithUser(ctx, &teamvite.Player{ID: userID})
+	}
+
+	divisions, _, err := NewDivisionService(db).FindDivisions(ctx(2, 2), teamvite.DivisionFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(divisions) != 1 || divisions[0].Name != "m2" {
+		t.Errorf("seattle divisions = %v; want m2", divisions)
+	}
+	// names only have to be unique within an organization
+	if err := NewDivisionService(db).CreateDivision(ctx(1, 1), &teamvite.Division{Name: "m2"}); err != nil {
+		t.Errorf("create m2 in default organization: %v", err)
+	}
+	// administrators only manage their own organization
+	if err := NewDivisionService(db).CreateDivision(ctx(2, 1), &teamvite.Division{Name: "m3"}); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+		t.Errorf("create in another organization: err = %v; want unauthorized", err)
+	}
+	admin, err := NewPlayerService(db).FindPlayerByID(ctx(2, 1), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if admin.IsAdmin {
+		t.Errorf("admin of another organization IsAdmin = true; want false")
+	}
+
+	seasons, _, err := NewSeasonService(db).FindSeasons(ctx(1, 2), teamvite.SeasonFilter{Name: "2026-fall"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seasons) != 1 || seasons[0].ID != 1 {
+		t.Errorf("default organization seasons = %v; want season 1", seasons)
+	}
+
+	// team lists are of the organization, but a player's teams are found
+	// from any
+	ts := NewTeamService(db)
+	teams, _, err := ts.FindTeams(ctx(1, 2), teamvite.TeamFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(teams) != 1 || teams[0].ID != 1 {
+		t.Errorf("default organization teams = %v; want Blue", teams)
+	}
+	if _, err := ts.FindTeamByID(ctx(1, 2), 2); err != nil {
+		t.Errorf("find other organization's team by id: %v", err)
+	}
+
+	// games can't be in another league's season
+	gameTime := time.Now().Add(48 * time.Hour)
+	err = NewGameService(db).CreateGame(ctx(1, 2), &teamvite.Game{TeamID: 1, SeasonID: 2, Time: &gameTime})
+	if teamvite.ErrorCode(err) != teamvite.EINVALID {
+		t.Errorf("game in another organization's season: err = %v; want invalid", err)
+	}
+	if err := NewGameService(db).CreateGame(ctx(1, 2), &teamvite.Game{TeamID: 1, SeasonID: 1, Time: &gameTime}); err != nil {
+		t.Errorf("game in the team's season: %v", err)
+	}
+}
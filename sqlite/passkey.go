@@ -0,0 +1,143 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/benprew/teamvite"
+)
+
+type PasskeyService struct {
+	db *sql.DB
+}
+
+// Ensure service implements interface.
+var _ teamvite.PasskeyService = (*PasskeyService)(nil)
+
+// NewPasskeyService returns a new instance of PasskeyService.
+func NewPasskeyService(db *sql.DB) *PasskeyService {
+	return &PasskeyService{db: db}
+}
+
+func (s *PasskeyService) FindPasskeys(ctx context.Context, playerID uint64) ([]*teamvite.Passkey, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return findPasskeys(ctx, tx, "player_id = ?", playerID)
+}
+
+func (s *PasskeyService) FindPasskeyByCredentialID(ctx context.Context, credentialID []byte) (*teamvite.Passkey, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	passkeys, err := findPasskeys(ctx, tx, "credential_id = ?", credentialID)
+	if err != nil {
+		return nil, err
+	} else if len(passkeys) == 0 {
+		return nil, teamvite.Errorf(teamvite.ENOTFOUND, "That passkey isn't registered, log in with your password or a login link.")
+	}
+	return passkeys[0], nil
+}
+
+func (s *PasskeyService) CreatePasskey(ctx context.Context, passkey *teamvite.Passkey) error {
+	passkey.Name = strings.TrimSpace(passkey.Name)
+	if passkey.Name == "" {
+		passkey.Name = "Passkey"
+	}
+	passkey.CreatedOn = time.Now()
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO passkeys (player_id, name, credential_id, public_key, sign_count, created_on)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		passkey.PlayerID, passkey.Name, passkey.CredentialID, passkey.PublicKey, passkey.SignCount, passkey.CreatedOn,
+	)
+	if err = FormatError(err); teamvite.ErrorCode(err) == teamvite.ECONFLICT {
+		return teamvite.Errorf(teamvite.ECONFLICT, "That passkey is already registered.")
+	} else if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	passkey.ID = uint64(id)
+	return nil
+}
+
+func (s *PasskeyService) UsePasskey(ctx context.Context, id uint64, signCount uint32) error {
+	_, err := s.db.ExecContext(ctx,
+		"update passkeys set sign_count = ?, last_used_on = ? where id = ?", signCount, time.Now(), id)
+	return err
+}
+
+func (s *PasskeyService) DeletePasskey(ctx context.Context, playerID, id uint64) error {
+	result, err := s.db.ExecContext(ctx, "delete from passkeys where id = ? and player_id = ?", id, playerID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return teamvite.Errorf(teamvite.ENOTFOUND, "Passkey not found.")
+	}
+	return nil
+}
+
+func (s *PasskeyService) CreatePasskeyChallenge(ctx context.Context, challenge string, playerID uint64) error {
+	_, err := s.db.ExecContext(ctx,
+		"insert into passkey_challenges (challenge, player_id, expires_on) values (?, ?, ?)",
+		challenge, playerID, time.Now().Add(teamvite.PasskeyChallengeLength).Unix())
+	return FormatError(err)
+}
+
+func (s *PasskeyService) UsePasskeyChallenge(ctx context.Context, challenge string) (uint64, error) {
+	var playerID uint64
+	var expiresOn time.Time
+	// deleting the challenge as it's read means it can only be used once
+	err := s.db.QueryRowContext(ctx, `
+		DELETE FROM passkey_challenges
+		WHERE challenge = ?
+		RETURNING player_id, expires_on`,
+		challenge).Scan(&playerID, &expiresOn)
+	if err == sql.ErrNoRows || (err == nil && time.Now().After(expiresOn)) {
+		return 0, teamvite.Errorf(teamvite.ENOTFOUND, "That passkey request expired, please try again.")
+	} else if err != nil {
+		return 0, err
+	}
+	return playerID, nil
+}
+
+func findPasskeys(ctx context.Context, tx *sql.Tx, where string, args ...interface{}) ([]*teamvite.Passkey, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, player_id, name, credential_id, public_key, sign_count, created_on, last_used_on
+		FROM passkeys
+		WHERE `+where+`
+		ORDER BY created_on DESC, id DESC`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	passkeys := []*teamvite.Passkey{}
+	for rows.Next() {
+		var p teamvite.Passkey
+		var lastUsedOn sql.NullTime
+		if err := rows.Scan(&p.ID, &p.PlayerID, &p.Name, &p.CredentialID, &p.PublicKey, &p.SignCount, &p.CreatedOn, &lastUsedOn); err != nil {
+			return nil, err
+		}
+		if lastUsedOn.Valid {
+			p.LastUsedOn = &lastUsedOn.Time
+		}
+		passkeys = append(passkeys, &p)
+	}
+	return passkeys, rows.Err()
+}
@@ -0,0 +1,81 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benprew/teamvite"
+)
+
+func TestPasskeys(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`insert into players (id, name, email) values (1, 'Player', 'p@example.com'), (2, 'Other', 'o@example.com');`)
+	panicIf(err)
+
+	ps := NewPasskeyService(db)
+	ctx := context.Background()
+	phone := teamvite.Passkey{PlayerID: 1, Name: " Phone ", CredentialID: []byte{1, 2, 3}, PublicKey: []byte{4}}
+	if err := ps.CreatePasskey(ctx, &phone); err != nil {
+		t.Fatal(err)
+	}
+	laptop := teamvite.Passkey{PlayerID: 1, CredentialID: []byte{5, 6}, PublicKey: []byte{7}}
+	if err := ps.CreatePasskey(ctx, &laptop); err != nil {
+		t.Fatal(err)
+	}
+	dup := teamvite.Passkey{PlayerID: 2, CredentialID: []byte{1, 2, 3}, PublicKey: []byte{4}}
+	if err := ps.CreatePasskey(ctx, &dup); teamvite.ErrorCode(err) != teamvite.ECONFLICT {
+		t.Errorf("duplicate credential: err = %v; want conflict", err)
+	}
+
+	passkeys, err := ps.FindPasskeys(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(passkeys) != 2 || passkeys[0].Name != "Passkey" || passkeys[1].Name != "Phone" {
+		t.Errorf("passkeys = %v, %v", passkeys[0], passkeys[1])
+	}
+
+	if err := ps.UsePasskey(ctx, phone.ID, 7); err != nil {
+		t.Fatal(err)
+	}
+	found, err := ps.FindPasskeyByCredentialID(ctx, []byte{1, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.ID != phone.ID || found.SignCount != 7 || found.LastUsedOn == nil {
+		t.Errorf("found passkey = %v", found)
+	}
+
+	// players can only remove their own passkeys
+	if err := ps.DeletePasskey(ctx, 2, phone.ID); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("delete other's passkey: err = %v; want not found", err)
+	}
+	if err := ps.DeletePasskey(ctx, 1, phone.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ps.FindPasskeyByCredentialID(ctx, []byte{1, 2, 3}); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("find deleted passkey: err = %v; want not found", err)
+	}
+}
+
+func TestPasskeyChallenge(t *testing.T) {
+	db := openTestDB(t)
+	ps := NewPasskeyService(db)
+	ctx := context.Background()
+
+	if err := ps.CreatePasskeyChallenge(ctx, "abc", 3); err != nil {
+		t.Fatal(err)
+	}
+	if playerID, err := ps.UsePasskeyChallenge(ctx, "abc"); err != nil || playerID != 3 {
+		t.Errorf("use challenge = %d, %v", playerID, err)
+	}
+	if _, err := ps.UsePasskeyChallenge(ctx, "abc"); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("reuse challenge: err = %v; want not found", err)
+	}
+
+	_, err := db.Exec("insert into passkey_challenges (challenge, expires_on) values ('old', strftime('%s', 'now', '-1 minute'))")
+	panicIf(err)
+	if _, err := ps.UsePasskeyChallenge(ctx, "old"); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("expired challenge: err = %v; want not found", err)
+	}
+}
@@ -0,0 +1,95 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/benprew/teamvite"
+)
+
+type PasswordResetService struct {
+	db *sql.DB
+}
+
+// Ensure service implements interface.
+var _ teamvite.PasswordResetService = (*PasswordResetService)(nil)
+
+// NewPasswordResetService returns a new instance of PasswordResetService.
+func NewPasswordResetService(db *sql.DB) *PasswordResetService {
+	return &PasswordResetService{db: db}
+}
+
+func (s *PasswordResetService) CreatePasswordReset(ctx context.Context, email string) (*teamvite.PasswordReset, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	reset := teamvite.PasswordReset{
+		Token:     genToken(),
+		ExpiresOn: time.Now().UTC().Add(teamvite.PasswordResetLength),
+	}
+	err = tx.QueryRowContext(ctx,
+		"select id, email from players where lower(email) = ?", strings.ToLower(strings.TrimSpace(email)),
+	).Scan(&reset.PlayerID, &reset.Email)
+	if err == sql.ErrNoRows {
+		return nil, teamvite.Errorf(teamvite.ENOTFOUND, "No player found for email: %s", email)
+	} else if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"insert into password_resets (token, player_id, expires_on) values (?, ?, ?)",
+		reset.Token, reset.PlayerID, reset.ExpiresOn.Unix())
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	return &reset, tx.Commit()
+}
+
+func (s *PasswordResetService) FindPasswordReset(ctx context.Context, token string) (*teamvite.PasswordReset, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return findPasswordReset(ctx, tx, token)
+}
+
+func (s *PasswordResetService) UsePasswordReset(ctx context.Context, token string) (*teamvite.PasswordReset, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	reset, err := findPasswordReset(ctx, tx, token)
+	if err != nil {
+		return nil, err
+	}
+	// a new password makes the player's other links useless too
+	if _, err := tx.ExecContext(ctx, "delete from password_resets where player_id = ?", reset.PlayerID); err != nil {
+		return nil, err
+	}
+	return reset, tx.Commit()
+}
+
+func findPasswordReset(ctx context.Context, tx *sql.Tx, token string) (*teamvite.PasswordReset, error) {
+	reset := teamvite.PasswordReset{Token: token}
+	err := tx.QueryRowContext(ctx, `
+		select r.player_id, p.email, r.expires_on
+		from password_resets r
+		join players p on p.id = r.player_id
+		where r.token = ?`, token,
+	).Scan(&reset.PlayerID, &reset.Email, &reset.ExpiresOn)
+	if err == sql.ErrNoRows || (err == nil && time.Now().After(reset.ExpiresOn)) {
+		return nil, teamvite.Errorf(teamvite.ENOTFOUND, "That reset link isn't valid, it may have expired or already been used.")
+	} else if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
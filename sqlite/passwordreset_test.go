@@ -0,0 +1,61 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benprew/teamvite"
+)
+
+func TestPasswordReset(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into players (id, name, email, password) values (1, 'Player', 'p@example.com', 'hash');
+		insert into sessions (id, player_id, ip) values ('a', 1, '127.0.0.1'), ('b', 1, '127.0.0.2');`)
+	panicIf(err)
+
+	rs := NewPasswordResetService(db)
+	ctx := context.Background()
+	if _, err := rs.CreatePasswordReset(ctx, "nobody@example.com"); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("unknown email: err = %v; want not found", err)
+	}
+	first, err := rs.CreatePasswordReset(ctx, "P@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := rs.CreatePasswordReset(ctx, "p@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, err := rs.FindPasswordReset(ctx, first.Token); err != nil || found.PlayerID != 1 {
+		t.Errorf("find reset = %v, %v", found, err)
+	}
+
+	if _, err := rs.UsePasswordReset(ctx, second.Token); err != nil {
+		t.Fatal(err)
+	}
+	// tokens work once, and using one uses up the player's others
+	for _, token := range []string{first.Token, second.Token} {
+		if _, err := rs.UsePasswordReset(ctx, token); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+			t.Errorf("reuse reset: err = %v; want not found", err)
+		}
+	}
+
+	expired, err := rs.CreatePasswordReset(ctx, "p@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec("update password_resets set expires_on = ? where token = ?", expired.ExpiresOn.AddDate(0, 0, -1).Unix(), expired.Token)
+	panicIf(err)
+	if _, err := rs.FindPasswordReset(ctx, expired.Token); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("expired reset: err = %v; want not found", err)
+	}
+
+	ss := NewSessionService(db)
+	if err := ss.RevokeAll(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ss.Load("a", nil); err == nil {
+		t.Errorf("session a still valid after RevokeAll")
+	}
+}
@@ -3,6 +3,7 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"strings"
 
 	"github.com/benprew/teamvite"
 	"golang.org/x/crypto/bcrypt"
@@ -48,25 +49,70 @@ func (ps *PlayerService) FindPlayers(ctx context.Context, filter teamvite.Player
 	}
 	defer tx.Rollback()
 
+	// rosters are limited by the team's privacy
+	var teamID uint64
+	if filter.TeamID != nil {
+		teamID = *filter.TeamID
+	} else if filter.GameID != nil {
+		err := tx.QueryRowContext(ctx, "select team_id from games where id = ?", *filter.GameID).Scan(&teamID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, 0, err
+		}
+	}
+	var team *teamvite.Team
+	if teamID != 0 {
+		teams, _, err := findTeams(ctx, tx, teamvite.TeamFilter{ID: teamID})
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(teams) == 0 || !teams[0].RosterVisible() {
+			return []*teamvite.Player{}, 0, nil
+		}
+		team = teams[0]
+	}
+
 	players, n, err := findPlayers(ctx, tx, filter)
-	return players, n, err
+	if err != nil {
+		return nil, 0, err
+	}
+	if team != nil && !team.IsMember {
+		userID := teamvite.UserIDFromContext(ctx)
+		for _, p := range players {
+			if p.ID != userID {
+				p.Email, p.Phone = "", 0
+			}
+		}
+	}
+	return players, n, nil
 }
 
 func (ps *PlayerService) Teams(ctx context.Context, teamIDs ...uint64) ([]teamvite.PlayerTeam, error) {
 	var playerTeams []teamvite.PlayerTeam
 	var args []interface{}
 
-	args = append(args, teamvite.PlayerFromContext(ctx).ID)
+	// hidden teams are only listed for their players and league administrators
+	userID := teamvite.UserIDFromContext(ctx)
+	args = append(args, userID, userID, teamvite.PlayerFromContext(ctx).ID)
 
 	query := `
 		SELECT
-			t.*,
+			t.id,
+			t.name,
+			t.division_id,
+			t.privacy,
 			pt.remind_email,
-			pt.remind_sms
+			pt.remind_sms,
+			pt.default_status,
+			pt.positions
 		FROM teams t
 			JOIN players_teams pt
 			ON t.id = pt.team_id
-		WHERE player_id = ?`
+			LEFT JOIN divisions d
+			ON t.division_id = d.id
+		WHERE (t.privacy != 'hidden'
+			OR t.id IN (SELECT team_id FROM players_teams WHERE player_id = ?)
+			OR d.organization_id IN (SELECT organization_id FROM organization_admins WHERE player_id = ?))
+			AND pt.player_id = ?`
 
 	if len(teamIDs) > 0 {
 		query += " and t.id in ?"
@@ -81,7 +127,7 @@ func (ps *PlayerService) Teams(ctx context.Context, teamIDs ...uint64) ([]teamvi
 
 	for rows.Next() {
 		var pt teamvite.PlayerTeam
-		err := rows.Scan(&pt.Team.ID, &pt.Team.Name, &pt.Team.DivisionID, &pt.RemindEmail, &pt.RemindSMS)
+		err := rows.Scan(&pt.Team.ID, &pt.Team.Name, &pt.Team.DivisionID, &pt.Team.Privacy, &pt.RemindEmail, &pt.RemindSMS, &pt.DefaultStatus, &pt.Positions)
 		if err != nil {
 			return nil, err
 		}
@@ -143,8 +189,8 @@ func (ps *PlayerService) UpdatePlayer(ctx context.Context) error {
 func (ps *PlayerService) UpdatePlayerTeam(ctx context.Context, playerTeam *teamvite.PlayerTeam) error {
 	playerID := teamvite.UserIDFromContext(ctx)
 	_, err := ps.db.Exec(
-		"update players_teams set remind_email = ?, remind_sms = ? where player_id = ? and team_id = ?",
-		playerTeam.RemindEmail, playerTeam.RemindSMS, playerID, playerTeam.Team.ID)
+		"update players_teams set remind_email = ?, remind_sms = ?, default_status = ?, positions = ? where player_id = ? and team_id = ?",
+		playerTeam.RemindEmail, playerTeam.RemindSMS, playerTeam.DefaultStatus, playerTeam.Positions, playerID, playerTeam.Team.ID)
 	return err
 }
 
@@ -163,12 +209,31 @@ func (ps *PlayerService) ResetPassword(ctx context.Context, password string) err
 func findPlayers(ctx context.Context, tx *sql.Tx, filter teamvite.PlayerFilter) (_ []*teamvite.Player, n int, err error) {
 	var players []*teamvite.Player
 	var query string
-	var args []interface{}
+	// administrators are of the request's organization
+	args := []interface{}{ownerOrganizationID(ctx)}
+
+	// roster details are only known when finding players by team
+	membership := "false, '', '', '', ''"
+	join := ""
+	if filter.TeamID != nil {
+		membership = `coalesce(pt.is_manager, false), coalesce(pt.jersey, ''), coalesce(pt.positions, ''),
+			coalesce(pt.roster_status, ''),
+			coalesce((
+				select pts.status from players_teams_seasons pts
+				where pts.player_id = p.id and pts.team_id = ?
+					and pts.season_id = coalesce(?, (select season_id from teams where id = ?))), '')`
+		args = append(args, *filter.TeamID, filter.SeasonID, *filter.TeamID)
+		join = "left join players_teams pt on pt.player_id = p.id and pt.team_id = ?"
+		args = append(args, *filter.TeamID)
+	}
 
 	query = `
 		select
-			p.id, p.name, p.email, p.phone, p.password
+			p.id, p.name, p.email, p.phone, p.password,
+			exists(select 1 from organization_admins a where a.player_id = p.id and a.organization_id = ?),
+			` + membership + `
 		from players p
+		` + join + `
 		where 1 = 1
 	`
 
@@ -182,13 +247,29 @@ func findPlayers(ctx context.Context, tx *sql.Tx, filter teamvite.PlayerFilter)
 		args = append(args, *filter.Name)
 	}
 
-	if filter.TeamID != nil {
-		query += " and id in (select player_id from players_teams where team_id = ?)"
+	if filter.TeamID != nil && filter.SeasonID != nil {
+		query += " and p.id in (select player_id from players_teams_seasons where team_id = ? and season_id = ? and status != ?)"
+		args = append(args, *filter.TeamID, *filter.SeasonID, teamvite.MembershipDeclined)
+	} else if filter.TeamID != nil {
+		query += " and p.id in (select player_id from players_teams where team_id = ?)"
 		args = append(args, *filter.TeamID)
 	}
 
+	if filter.GameID != nil {
+		query += " and p.id in (select player_id from players_games where game_id = ?"
+		args = append(args, *filter.GameID)
+		if len(filter.GameStatuses) > 0 {
+			query += " and status in (" + strings.TrimSuffix(strings.Repeat("?,", len(filter.GameStatuses)), ",") + ")"
+			for _, status := range filter.GameStatuses {
+				args = append(args, status)
+			}
+		}
+		query += ")"
+	}
+
+	// emails were stored as entered, so match them ignoring case
 	if filter.Email != "" {
-		query += " and p.email = ?"
+		query += " and lower(p.email) = lower(?)"
 		args = append(args, filter.Email)
 	}
 
@@ -205,7 +286,9 @@ func findPlayers(ctx context.Context, tx *sql.Tx, filter teamvite.PlayerFilter)
 
 	for rows.Next() {
 		var p teamvite.Player
-		err := rows.Scan(&p.ID, &p.Name, &p.Email, &p.Phone, &p.Password)
+		err := rows.Scan(
+			&p.ID, &p.Name, &p.Email, &p.Phone, &p.Password, &p.IsAdmin,
+			&p.IsManager, &p.Jersey, &p.Positions, &p.RosterStatus, &p.SeasonStatus)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -0,0 +1,23 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benprew/teamvite"
+)
+
+func TestFindPlayersByEmail(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec("insert into players (id, name, email) values (1, 'Mixed', 'Mixed.Case@Example.com')")
+	panicIf(err)
+
+	// login links look players up by the lowercased email
+	players, n, err := NewPlayerService(db).FindPlayers(context.Background(), teamvite.PlayerFilter{Email: "mixed.case@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || players[0].ID != 1 {
+		t.Errorf("found %v; want player 1", players)
+	}
+}
@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"unicode"
+
+	"github.com/benprew/teamvite"
+)
+
+type SearchService struct {
+	db *sql.DB
+}
+
+// Ensure service implements interface.
+var _ teamvite.SearchService = (*SearchService)(nil)
+
+// NewSearchService returns a new instance of SearchService.
+func NewSearchService(db *sql.DB) *SearchService {
+	return &SearchService{db: db}
+}
+
+func (s *SearchService) Search(ctx context.Context, query string, limit int) ([]*teamvite.SearchResult, error) {
+	match := searchMatch(query)
+	if match == "" {
+		return []*teamvite.SearchResult{}, nil
+	}
+	if limit <= 0 || limit > teamvite.MaxSearchResults {
+		limit = teamvite.MaxSearchResults
+	}
+
+	// teams and divisions are of the request's organization, players are
+	// found from any
+	userID, orgID := teamvite.UserIDFromContext(ctx), teamvite.OrganizationIDFromContext(ctx)
+	rows, err := s.db.QueryContext(ctx, `
+		select s.kind, s.item_id, s.name
+		from search_index s
+		left join teams t on s.kind = 'team' and t.id = s.item_id
+		left join divisions d on d.id = case s.kind when 'team' then t.division_id when 'division' then s.item_id end
+		where search_index match ? and (s.kind = 'player' or ? = 0 or d.organization_id = ?) and (
+			s.kind = 'division'
+			or (s.kind = 'team' and (t.privacy != 'hidden'
+				or exists (select 1 from players_teams where team_id = t.id and player_id = ?)))
+			or (s.kind = 'player' and (s.item_id = ?
+				or exists (select 1 from players_teams a join players_teams b on a.team_id = b.team_id
+					where a.player_id = s.item_id and b.player_id = ?))))
+		order by rank
+		limit ?`,
+		match, orgID, orgID, userID, userID, userID, limit)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	results := make([]*teamvite.SearchResult, 0)
+	for rows.Next() {
+		var r teamvite.SearchResult
+		if err := rows.Scan(&r.Type, &r.ID, &r.Name); err != nil {
+			return nil, err
+		}
+		results = append(results, &r)
+	}
+	return results, rows.Err()
+}
+
+// searchMatch turns a query into an FTS5 match of words starting with each of
+// its words. Punctuation is dropped the same way as the index's compact
+// column, so "F.C." finds "FC" and the other way around.
+func searchMatch(query string) string {
+	query = strings.NewReplacer(".", "", "'", "", "-", "").Replace(query)
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = `"` + w + `"*`
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,59 @@
+//go:build sqlite_fts5
+
+package sqlite
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/benprew/teamvite"
+)
+
+func TestSearch(t *testing.T) {
+	db := openTestDB(t)
+	schema, err := os.ReadFile("../db/search.sql")
+	panicIf(err)
+	_, err = db.Exec(string(schema))
+	panicIf(err)
+	_, err = db.Exec(`
+		insert into divisions (id, name) values (1, 'Coed C1');
+		insert into teams (id, name, division_id, privacy) values (1, 'Portland F.C.', 1, 'public'), (2, 'FC Hidden', 1, 'hidden');
+		insert into players (id, name, email) values (1, 'Fiona', 'f@example.com'), (2, 'Frank', 'x@example.com'), (3, 'Fred', 'y@example.com');
+		insert into players_teams (player_id, team_id) values (1, 1), (2, 1);
+		update teams set name = 'Rose City F.C.' where id = 1;`)
+	panicIf(err)
+	// applying it again reindexes without duplicating rows
+	_, err = db.Exec(string(schema))
+	panicIf(err)
+
+	ss := NewSearchService(db)
+	ctx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 1})
+	names := func(q string) (names []string) {
+		results, err := ss.Search(ctx, q, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range results {
+			names = append(names, r.Type+":"+r.Name)
+		}
+		return names
+	}
+
+	// punctuation doesn't matter and hidden teams are left out
+	for _, q := range []string{"FC", "F.C.", "rose f.c"} {
+		if got := names(q); len(got) != 1 || got[0] != "team:Rose City F.C." {
+			t.Errorf("search %q = %v; want renamed team", q, got)
+		}
+	}
+	// prefixes match, and only teammates are found
+	if got := names("fr"); len(got) != 1 || got[0] != "player:Frank" {
+		t.Errorf("search fr = %v; want Frank", got)
+	}
+	if got := names("coed"); len(got) != 1 || got[0] != "division:Coed C1" {
+		t.Errorf("search coed = %v; want division", got)
+	}
+	if got := names(`" * (`); len(got) != 0 {
+		t.Errorf("search of punctuation = %v; want nothing", got)
+	}
+}
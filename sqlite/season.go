@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"strings"
+	"time"
 
 	"github.com/benprew/teamvite"
 )
@@ -31,12 +32,132 @@ func (s *SeasonService) FindSeasons(ctx context.Context, filter teamvite.SeasonF
 	return seasons, n, err
 }
 
+func (s *SeasonService) CurrentSeason(ctx context.Context) (*teamvite.Season, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return currentSeason(ctx, tx)
+}
+
+func currentSeason(ctx context.Context, tx *sql.Tx) (*teamvite.Season, error) {
+	open := false
+	seasons, _, err := findSeasons(ctx, tx, teamvite.SeasonFilter{Closed: &open})
+	if err != nil {
+		return nil, err
+	}
+
+	// seasons without dates count as starting before any with them
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	playing := func(s *teamvite.Season) bool {
+		return s.EndOn.IsZero() || !s.EndOn.Before(today)
+	}
+	var current, upcoming *teamvite.Season
+	for _, season := range seasons {
+		if season.StartOn.After(today) {
+			if upcoming == nil || season.StartOn.Before(upcoming.StartOn) {
+				upcoming = season
+			}
+			continue
+		}
+		if current == nil || (playing(season) && !playing(current)) ||
+			(playing(season) == playing(current) && season.StartOn.After(current.StartOn)) {
+			current = season
+		}
+	}
+	// between seasons the next one is current
+	if current == nil {
+		current = upcoming
+	}
+	if current == nil {
+		return nil, teamvite.Errorf(teamvite.ENOTFOUND, "There isn't a current season.")
+	}
+	return current, nil
+}
+
+func (s *SeasonService) CreateSeason(ctx context.Context, season *teamvite.Season) error {
+	season.Name = strings.TrimSpace(season.Name)
+	if season.Name == "" {
+		return teamvite.Errorf(teamvite.EINVALID, "Season name is required.")
+	}
+	if season.StartOn.IsZero() || season.EndOn.IsZero() {
+		return teamvite.Errorf(teamvite.EINVALID, "Season start and end dates are required.")
+	}
+	if season.EndOn.Before(season.StartOn) {
+		return teamvite.Errorf(teamvite.EINVALID, "Season must end after it starts.")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := checkAdmin(ctx, tx); err != nil {
+		return err
+	}
+
+	season.StartOn, season.EndOn = season.StartOn.UTC(), season.EndOn.UTC()
+	season.OrganizationID = ownerOrganizationID(ctx)
+	result, err := tx.ExecContext(ctx,
+		"insert into seasons (organization_id, name, start_on, end_on) values (?, ?, ?, ?)",
+		season.OrganizationID, season.Name, season.StartOn, season.EndOn)
+	if err = FormatError(err); teamvite.ErrorCode(err) == teamvite.ECONFLICT {
+		return teamvite.Errorf(teamvite.ECONFLICT, "A season named %s already exists.", season.Name)
+	} else if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	season.ID = int(id)
+	season.Closed = false
+	return tx.Commit()
+}
+
+func (s *SeasonService) CloseSeason(ctx context.Context, id uint64) (*teamvite.Season, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := checkAdmin(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	seasons, _, err := findSeasons(ctx, tx, teamvite.SeasonFilter{ID: &id})
+	if err != nil {
+		return nil, err
+	}
+	if len(seasons) == 0 {
+		return nil, teamvite.Errorf(teamvite.ENOTFOUND, "Season not found: %v", id)
+	}
+	season := seasons[0]
+	if season.Closed {
+		return season, teamvite.Errorf(teamvite.ECONFLICT, "%s is already closed.", season.Name)
+	}
+
+	if _, err := tx.ExecContext(ctx, "update seasons set closed = true where id = ?", id); err != nil {
+		return nil, FormatError(err)
+	}
+	season.Closed = true
+	return season, tx.Commit()
+}
+
 func findSeasons(ctx context.Context, tx *sql.Tx, filter teamvite.SeasonFilter) (_ []*teamvite.Season, n int, err error) {
 	var seasons []*teamvite.Season
 
 	// Build WHERE clause.
 	var where []string
 	var args []interface{}
+	if orgID := teamvite.OrganizationIDFromContext(ctx); orgID != 0 {
+		where = append(where, "organization_id = ?")
+		args = append(args, orgID)
+	}
 	if filter.ID != nil {
 		where = append(where, "id = ?")
 		args = append(args, *filter.ID)
@@ -45,12 +166,20 @@ func findSeasons(ctx context.Context, tx *sql.Tx, filter teamvite.SeasonFilter)
 		where = append(where, "name like ?")
 		args = append(args, "%"+filter.Name+"%")
 	}
+	if filter.Closed != nil {
+		where = append(where, "closed = ?")
+		args = append(args, *filter.Closed)
+	}
 
 	// Build query.
 	query := `
 		select
 			id,
-			name
+			organization_id,
+			name,
+			start_on,
+			end_on,
+			closed,
 			COUNT(*) OVER()
 		from seasons
 	`
@@ -69,13 +198,19 @@ func findSeasons(ctx context.Context, tx *sql.Tx, filter teamvite.SeasonFilter)
 	// Iterate over rows.
 	for rows.Next() {
 		var season teamvite.Season
+		var startOn, endOn sql.NullTime
 		if err := rows.Scan(
 			&season.ID,
+			&season.OrganizationID,
 			&season.Name,
+			&startOn,
+			&endOn,
+			&season.Closed,
 			&n,
 		); err != nil {
 			return nil, 0, err
 		}
+		season.StartOn, season.EndOn = startOn.Time, endOn.Time
 		seasons = append(seasons, &season)
 	}
 
@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benprew/teamvite"
+)
+
+func TestSeasonLifecycle(t *testing.T) {
+	db := openTestDB(t)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	_, err := db.Exec(`
+		insert into divisions (id, name) values (1, 'm1');
+		insert into players (id, name, email) values (1, 'Admin', 'a@example.com'), (2, 'Manager', 'm@example.com');
+		insert into organization_admins (organization_id, player_id) values (1, 1);
+		insert into teams (id, name, division_id) values (1, 'Blue', 1);
+		insert into players_teams (player_id, team_id, is_manager) values (2, 1, true);`)
+	panicIf(err)
+
+	ss := NewSeasonService(db)
+	adminCtx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 1})
+	mgrCtx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 2})
+
+	playing := &teamvite.Season{Name: "2026-fall", StartOn: today.AddDate(0, -1, 0), EndOn: today.AddDate(0, 1, 0)}
+	if err := ss.CreateSeason(mgrCtx, playing); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+		t.Errorf("non-admin create: err = %v; want unauthorized", err)
+	}
+	if err := ss.CreateSeason(adminCtx, &teamvite.Season{Name: "backwards", StartOn: today, EndOn: today.AddDate(0, -1, 0)}); teamvite.ErrorCode(err) != teamvite.EINVALID {
+		t.Errorf("end before start: err = %v; want invalid", err)
+	}
+	if err := ss.CreateSeason(adminCtx, playing); err != nil {
+		t.Fatal(err)
+	}
+	// a later season that hasn't started doesn't replace the one being played
+	next := &teamvite.Season{Name: "2027-winter", StartOn: today.AddDate(0, 2, 0), EndOn: today.AddDate(0, 4, 0)}
+	if err := ss.CreateSeason(adminCtx, next); err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := ss.CurrentSeason(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.ID != playing.ID {
+		t.Errorf("current season = %s; want %s", current.Name, playing.Name)
+	}
+
+	gs := NewGameService(db)
+	gameTime := today.Add(36 * time.Hour)
+	if err := gs.CreateGame(mgrCtx, &teamvite.Game{TeamID: 1, SeasonID: uint64(playing.ID), Time: &gameTime}); err != nil {
+		t.Fatal(err)
+	}
+
+	closed, err := ss.CloseSeason(adminCtx, uint64(playing.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !closed.Closed {
+		t.Errorf("closed = false; want true")
+	}
+	if _, err := ss.CloseSeason(adminCtx, uint64(playing.ID)); teamvite.ErrorCode(err) != teamvite.ECONFLICT {
+		t.Errorf("close twice: err = %v; want conflict", err)
+	}
+
+	current, err = ss.CurrentSeason(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current.ID != next.ID {
+		t.Errorf("current season after close = %s; want %s", current.Name, next.Name)
+	}
+
+	// closed seasons don't take new games and drop out of upcoming games
+	gameTime = gameTime.Add(24 * time.Hour)
+	err = gs.CreateGame(mgrCtx, &teamvite.Game{TeamID: 1, SeasonID: uint64(playing.ID), Time: &gameTime})
+	if teamvite.ErrorCode(err) != teamvite.EINVALID {
+		t.Errorf("game in closed season: err = %v; want invalid", err)
+	}
+	games, _, err := gs.FindGames(context.Background(), teamvite.GameFilter{TeamID: 1, OpenSeasons: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 0 {
+		t.Errorf("games of open seasons = %v; want none", games)
+	}
+
+	team, err := NewTeamService(db).FindTeamByID(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = NewTeamService(db).StartSeason(mgrCtx, team, uint64(playing.ID))
+	if teamvite.ErrorCode(err) != teamvite.EINVALID {
+		t.Errorf("start closed season: err = %v; want invalid", err)
+	}
+}
@@ -2,9 +2,9 @@ package sqlite
 
 import (
 	"database/sql"
-	"fmt"
 	"log"
 	"net"
+	"sort"
 	"time"
 
 	"github.com/benprew/teamvite"
@@ -91,6 +91,7 @@ func (ss *SessionService) New(playerID uint64, IP net.IP, sessionLen time.Durati
 		PlayerID:  playerID,
 		IP:        IP,
 		ExpiresOn: time.Now().Add(sessionLen),
+		CreatedOn: time.Now(),
 	}
 	return s, ss.SaveSession(s)
 }
@@ -99,32 +100,25 @@ func (ss *SessionService) SaveSession(s teamvite.Session) error {
 	ipStr := s.IP.String()
 
 	_, err := ss.db.Exec(
-		`INSERT INTO SESSIONS (id, player_id, ip, expires_on)
-		VALUES (?, ?, ?, ?)`,
-		s.ID, s.PlayerID, ipStr, s.ExpiresOn)
+		`INSERT INTO SESSIONS (id, player_id, ip, expires_on, two_factor, created_on)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		s.ID, s.PlayerID, ipStr, s.ExpiresOn.Unix(), s.TwoFactor, s.CreatedOn)
 	return err
 }
 
 // the Load verb is common in the codebase and means to load a struct from the database
 func (ss *SessionService) Load(sid string, ip net.IP) (teamvite.Session, error) {
-	var ipStr string
-	s := teamvite.Session{}
-	row := ss.db.QueryRow(
-		`SELECT id, player_id, ip, expires_on
-		FROM sessions
-		WHERE id = ? AND expires_on >= ?`,
-		sid,
-		time.Now().Unix())
-	err := row.Scan(&s.ID, &s.PlayerID, &ipStr, &s.ExpiresOn)
+	sessions, err := findSessions(ss.db, "id = ? AND NOT login_token AND expires_on >= ?", sid, time.Now().Unix())
 	if err != nil {
 		return teamvite.Session{}, err
+	} else if len(sessions) == 0 {
+		return teamvite.Session{}, sql.ErrNoRows
 	}
-	ip = net.ParseIP(ipStr)
+	s := sessions[0]
+	// phones and laptops change address all the time, so sessions aren't
+	// tied to the one they were made from
 	if ip != nil && s.IP != nil && !ip.Equal(s.IP) {
-		s.IP = ip
-		msg := fmt.Sprintf("ip mismatch, possible session hijacking [req=%s db=%s]", ip, s.IP)
-		log.Printf("[WARN] %s\n", msg)
-		return teamvite.Session{}, fmt.Errorf(msg)
+		log.Printf("[INFO] session used from a new ip [player_id=%d req=%s db=%s]", s.PlayerID, ip, s.IP)
 	}
 	log.Printf("loaded session [sid=%s, ip=%s, session=%v]", sid, s.IP, s)
 	return s, nil
@@ -136,6 +130,141 @@ func (ss *SessionService) Revoke(sid string) error {
 	return err
 }
 
+func (ss *SessionService) RevokeAll(playerID uint64) error {
+	_, err := ss.db.Exec("delete from sessions where player_id = ?", playerID)
+	log.Printf("Revoked all sessions [player_id=%d]", playerID)
+	return err
+}
+
+func (ss *SessionService) NewLoginToken(playerID uint64, tokenLen time.Duration) (teamvite.Session, error) {
+	s := teamvite.Session{
+		ID:        genSessionID(25),
+		PlayerID:  playerID,
+		ExpiresOn: time.Now().Add(tokenLen),
+	}
+	_, err := ss.db.Exec(
+		`INSERT INTO sessions (id, player_id, ip, expires_on, login_token)
+		VALUES (?, ?, ?, ?, true)`,
+		s.ID, s.PlayerID, s.IP.String(), s.ExpiresOn.Unix())
+	log.Printf("created login token [player_id=%d]", playerID)
+	return s, err
+}
+
+func (ss *SessionService) RedeemLoginToken(token string, IP net.IP, sessionLen time.Duration) (teamvite.Session, error) {
+	var playerID uint64
+	var expiresOn time.Time
+	// deleting the token as it's read means it can only be redeemed once
+	err := ss.db.QueryRow(
+		`DELETE FROM sessions
+		WHERE id = ? AND login_token
+		RETURNING player_id, expires_on`,
+		token).Scan(&playerID, &expiresOn)
+	if err == sql.ErrNoRows || (err == nil && time.Now().After(expiresOn)) {
+		return teamvite.Session{}, teamvite.Errorf(teamvite.ENOTFOUND, "That login link isn't valid, it may have expired or already been used.")
+	} else if err != nil {
+		return teamvite.Session{}, err
+	}
+	return ss.New(playerID, IP, sessionLen)
+}
+
+func (ss *SessionService) FindLoginToken(token string) (teamvite.Session, error) {
+	s := teamvite.Session{ID: token}
+	err := ss.db.QueryRow(
+		`SELECT player_id, expires_on
+		FROM sessions
+		WHERE id = ? AND login_token`,
+		token).Scan(&s.PlayerID, &s.ExpiresOn)
+	if err == sql.ErrNoRows || (err == nil && time.Now().After(s.ExpiresOn)) {
+		return teamvite.Session{}, teamvite.Errorf(teamvite.ENOTFOUND, "That login has expired, please log in again.")
+	} else if err != nil {
+		return teamvite.Session{}, err
+	}
+	return s, nil
+}
+
+func (ss *SessionService) MarkTwoFactor(sid string) error {
+	_, err := ss.db.Exec("update sessions set two_factor = true where id = ?", sid)
+	return err
+}
+
+func (ss *SessionService) FindSessions(playerID uint64) ([]teamvite.Session, error) {
+	sessions, err := findSessions(ss.db, "player_id = ? AND NOT login_token AND expires_on >= ?", playerID, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return lastActive(sessions[i]).After(lastActive(sessions[j]))
+	})
+	return sessions, nil
+}
+
+// lastActive is when the session was last seen, or made if it hasn't been
+func lastActive(s teamvite.Session) time.Time {
+	if s.LastSeenOn.IsZero() {
+		return s.CreatedOn
+	}
+	return s.LastSeenOn
+}
+
+func (ss *SessionService) Touch(sid string) error {
+	_, err := ss.db.Exec("update sessions set last_seen_on = ? where id = ?", time.Now(), sid)
+	return err
+}
+
+func (ss *SessionService) RevokeSession(playerID uint64, key string) error {
+	// the key is a hash of the ID, so look through the player's sessions
+	sessions, err := findSessions(ss.db, "player_id = ? AND NOT login_token", playerID)
+	if err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		if s.Key() != key {
+			continue
+		}
+		if _, err := ss.db.Exec("delete from sessions where id = ?", s.ID); err != nil {
+			return err
+		}
+		log.Printf("Revoked session [player_id=%d key=%s]", playerID, key)
+		return nil
+	}
+	return teamvite.Errorf(teamvite.ENOTFOUND, "Session not found, it may have already been signed out.")
+}
+
+func (ss *SessionService) RevokeOthers(playerID uint64, sid string) error {
+	_, err := ss.db.Exec(
+		"delete from sessions where player_id = ? and id != ? and not login_token", playerID, sid)
+	log.Printf("Revoked other sessions [player_id=%d]", playerID)
+	return err
+}
+
+func findSessions(db *sql.DB, where string, args ...interface{}) ([]teamvite.Session, error) {
+	rows, err := db.Query(`
+		SELECT id, player_id, ip, expires_on, two_factor, created_on, last_seen_on
+		FROM sessions
+		WHERE `+where,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []teamvite.Session{}
+	for rows.Next() {
+		var s teamvite.Session
+		var ipStr string
+		var createdOn, lastSeenOn sql.NullTime
+		if err := rows.Scan(&s.ID, &s.PlayerID, &ipStr, &s.ExpiresOn, &s.TwoFactor, &createdOn, &lastSeenOn); err != nil {
+			return nil, err
+		}
+		s.IP = net.ParseIP(ipStr)
+		s.CreatedOn = createdOn.Time
+		s.LastSeenOn = lastSeenOn.Time
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
 func genSessionID(length uint) string {
 	return uniuri.NewLen(int(length))
 }
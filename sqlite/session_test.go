@@ -3,11 +3,13 @@ package sqlite
 import (
 	"net"
 	"testing"
+	"time"
 
+	"github.com/benprew/teamvite"
 	_ "github.com/mattn/go-sqlite3"
 )
 
-func TestLoadSessionIpMismatch(t *testing.T) {
+func TestLoadSessionIpChange(t *testing.T) {
 	db := Open(":memory:")
 	srv := NewSessionService(db)
 
@@ -17,6 +19,10 @@ CREATE TABLE sessions (
     player_id NOT NULL,
     ip varchar,
     expires_on datetime NOT NULL DEFAULT 2556144000, -- 1/1/2051
+    login_token boolean NOT NULL DEFAULT 0,
+    two_factor boolean NOT NULL DEFAULT 0,
+    created_on datetime,
+    last_seen_on datetime,
     FOREIGN KEY (player_id) REFERENCES players (id)
 );`)
 	panicIf(err)
@@ -25,12 +31,13 @@ CREATE TABLE sessions (
 	_, err = db.Exec("insert into sessions (id, player_id, ip) values (123, 123, '127.0.0.2')")
 	panicIf(err)
 
+	// sessions keep working when the player's address changes
 	s, err := srv.Load("123", net.ParseIP("127.0.0.1"))
-	if s.PlayerID != 0 {
-		t.Errorf("Session was valid [session=%v]", s)
+	if err != nil {
+		t.Errorf("loading session from a new ip: %s", err)
 	}
-	if err == nil {
-		t.Errorf("No error")
+	if s.PlayerID != 123 {
+		t.Errorf("Session wasn't loaded [session=%v]", s)
 	}
 }
 
@@ -39,3 +46,127 @@ func panicIf(err error) {
 		panic(err)
 	}
 }
+
+func TestLoginToken(t *testing.T) {
+	db := openTestDB(t)
+	srv := NewSessionService(db)
+
+	token, err := srv.NewLoginToken(1, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// login tokens can't be used as sessions
+	if _, err := srv.Load(token.ID, nil); err == nil {
+		t.Errorf("login token loaded as a session")
+	}
+
+	// finding the token doesn't use it up
+	if found, err := srv.FindLoginToken(token.ID); err != nil || found.PlayerID != 1 {
+		t.Errorf("find login token = %v, %v", found, err)
+	}
+
+	ip := net.ParseIP("127.0.0.1")
+	s, err := srv.RedeemLoginToken(token.ID, ip, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.PlayerID != 1 || s.ID == token.ID {
+		t.Errorf("redeemed session = %v", s)
+	}
+	if loaded, err := srv.Load(s.ID, ip); err != nil || loaded.TwoFactor {
+		t.Errorf("loading redeemed session = %v, %v", loaded, err)
+	}
+	if err := srv.MarkTwoFactor(s.ID); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err := srv.Load(s.ID, ip); err != nil || !loaded.TwoFactor {
+		t.Errorf("session after MarkTwoFactor = %v, %v", loaded, err)
+	}
+	if _, err := srv.RedeemLoginToken(token.ID, ip, time.Hour); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("redeem twice: err = %v; want not found", err)
+	}
+
+	expired, err := srv.NewLoginToken(1, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.FindLoginToken(expired.ID); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("find expired: err = %v; want not found", err)
+	}
+	if _, err := srv.RedeemLoginToken(expired.ID, ip, time.Hour); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("redeem expired: err = %v; want not found", err)
+	}
+}
+
+func TestLoadSessionExpired(t *testing.T) {
+	db := openTestDB(t)
+	srv := NewSessionService(db)
+
+	ip := net.ParseIP("127.0.0.1")
+	expired, err := srv.New(1, ip, -time.Minute)
+	panicIf(err)
+	if s, err := srv.Load(expired.ID, ip); err == nil {
+		t.Errorf("expired session loaded [session=%v]", s)
+	}
+
+	current, err := srv.New(1, ip, time.Hour)
+	panicIf(err)
+	if _, err := srv.Load(current.ID, ip); err != nil {
+		t.Errorf("loading unexpired session: %s", err)
+	}
+}
+
+func TestActiveSessions(t *testing.T) {
+	db := openTestDB(t)
+	srv := NewSessionService(db)
+
+	ip := net.ParseIP("127.0.0.1")
+	current, err := srv.New(1, ip, time.Hour)
+	panicIf(err)
+	other, err := srv.New(1, ip, time.Hour)
+	panicIf(err)
+	_, err = srv.New(1, ip, -time.Hour)
+	panicIf(err)
+	_, err = srv.NewLoginToken(1, time.Hour)
+	panicIf(err)
+	otherPlayer, err := srv.New(2, ip, time.Hour)
+	panicIf(err)
+	panicIf(srv.Touch(other.ID))
+
+	// expired sessions and login tokens aren't listed
+	sessions, err := srv.FindSessions(1)
+	panicIf(err)
+	if len(sessions) != 2 {
+		t.Fatalf("found %d sessions; want 2", len(sessions))
+	}
+	if sessions[0].ID != other.ID || sessions[0].LastSeenOn.IsZero() || sessions[0].CreatedOn.IsZero() {
+		t.Errorf("most recently seen session = %v", sessions[0])
+	}
+	if sessions[1].ID != current.ID || sessions[1].Key() == "" {
+		t.Errorf("session = %v", sessions[1])
+	}
+
+	// can't revoke another player's session
+	otherSessions, err := srv.FindSessions(2)
+	panicIf(err)
+	if err := srv.RevokeSession(1, otherSessions[0].Key()); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("revoking another player's session: err = %v", err)
+	}
+	// keys outlast vacuums, which can renumber rowids
+	_, err = db.Exec("vacuum")
+	panicIf(err)
+	if err := srv.RevokeSession(1, sessions[0].Key()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.Load(other.ID, ip); err == nil {
+		t.Errorf("revoked session loaded")
+	}
+
+	panicIf(srv.RevokeOthers(1, current.ID))
+	if sessions, _ := srv.FindSessions(1); len(sessions) != 1 || sessions[0].ID != current.ID {
+		t.Errorf("after revoking others = %v", sessions)
+	}
+	if _, err := srv.Load(otherPlayer.ID, ip); err != nil {
+		t.Errorf("other player's session was revoked: %s", err)
+	}
+}
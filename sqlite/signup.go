@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/benprew/teamvite"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type SignupService struct {
+	db *sql.DB
+}
+
+// Ensure service implements interface.
+var _ teamvite.SignupService = (*SignupService)(nil)
+
+// NewSignupService returns a new instance of SignupService.
+func NewSignupService(db *sql.DB) *SignupService {
+	return &SignupService{db: db}
+}
+
+func (s *SignupService) CreateSignup(ctx context.Context, signup *teamvite.Signup) error {
+	signup.Name = strings.TrimSpace(signup.Name)
+	signup.Email = strings.ToLower(strings.TrimSpace(signup.Email))
+	if signup.Name == "" {
+		return teamvite.Errorf(teamvite.EINVALID, "Name is required.")
+	}
+	if !strings.Contains(signup.Email, "@") {
+		return teamvite.Errorf(teamvite.EINVALID, "A valid email is required.")
+	}
+	if len(signup.Password) < teamvite.MinPasswordLength {
+		return teamvite.Errorf(teamvite.EINVALID, "Passwords must be at least %d characters.", teamvite.MinPasswordLength)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var hasPassword bool
+	err = tx.QueryRowContext(ctx,
+		"select password != '' from players where lower(email) = ?", signup.Email,
+	).Scan(&hasPassword)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if hasPassword {
+		return teamvite.Errorf(teamvite.ECONFLICT, "There is already an account for %s, log in instead.", signup.Email)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(signup.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	signup.Password = string(hash)
+	signup.Token = genToken()
+	signup.ExpiresOn = time.Now().UTC().Add(teamvite.SignupLength)
+
+	_, err = tx.ExecContext(ctx, `
+		insert into signups (token, name, email, password, phone, expires_on)
+		values (?, ?, ?, ?, ?, ?)`,
+		signup.Token, signup.Name, signup.Email, signup.Password, signup.Phone, signup.ExpiresOn.Unix())
+	if err != nil {
+		return FormatError(err)
+	}
+	return tx.Commit()
+}
+
+func (s *SignupService) VerifySignup(ctx context.Context, token string) (*teamvite.Player, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var signup teamvite.Signup
+	err = tx.QueryRowContext(ctx,
+		"select name, email, password, phone, expires_on from signups where token = ?", token,
+	).Scan(&signup.Name, &signup.Email, &signup.Password, &signup.Phone, &signup.ExpiresOn)
+	if err == sql.ErrNoRows {
+		return nil, teamvite.Errorf(teamvite.ENOTFOUND, "That link isn't valid, it may have already been used.")
+	} else if err != nil {
+		return nil, err
+	}
+	if time.Now().After(signup.ExpiresOn) {
+		return nil, teamvite.Errorf(teamvite.EINVALID, "That link has expired, please sign up again.")
+	}
+
+	var playerID uint64
+	var hasPassword bool
+	err = tx.QueryRowContext(ctx,
+		"select id, password != '' from players where lower(email) = ?", signup.Email,
+	).Scan(&playerID, &hasPassword)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// replies to text reminders are matched to players by phone
+	if signup.Phone != 0 {
+		var phoneTaken bool
+		err := tx.QueryRowContext(ctx,
+			"select exists (select 1 from players where phone = ? and id != ?)", signup.Phone, playerID,
+		).Scan(&phoneTaken)
+		if err != nil {
+			return nil, err
+		}
+		if phoneTaken {
+			return nil, teamvite.Errorf(teamvite.EINVALID, "That phone number is used by another account, please sign up again without it.")
+		}
+	}
+
+	switch {
+	case err == sql.ErrNoRows:
+		result, err := tx.ExecContext(ctx,
+			"insert into players (name, email, phone, password) values (?, ?, ?, ?)",
+			signup.Name, signup.Email, signup.Phone, signup.Password)
+		if err != nil {
+			return nil, FormatError(err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		playerID = uint64(id)
+	case hasPassword:
+		// the account was finished by another signup
+		return nil, teamvite.Errorf(teamvite.ECONFLICT, "There is already an account for %s, log in instead.", signup.Email)
+	default:
+		// claim the account a manager added, keeping its phone if the
+		// signup didn't have one
+		_, err = tx.ExecContext(ctx, `
+			update players set name = ?, password = ?, phone = case when ? != 0 then ? else phone end
+			where id = ?`,
+			signup.Name, signup.Password, signup.Phone, signup.Phone, playerID)
+		if err != nil {
+			return nil, FormatError(err)
+		}
+	}
+
+	// every signup for the email is done with
+	if _, err := tx.ExecContext(ctx, "delete from signups where email = ?", signup.Email); err != nil {
+		return nil, err
+	}
+
+	players, _, err := findPlayers(ctx, tx, teamvite.PlayerFilter{ID: &playerID})
+	if err != nil {
+		return nil, err
+	}
+	if len(players) == 0 {
+		return nil, teamvite.Errorf(teamvite.ENOTFOUND, "Player not found: %d", playerID)
+	}
+	return players[0], tx.Commit()
+}
@@ -0,0 +1,72 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benprew/teamvite"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSignup(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into divisions (id, name) values (1, 'm1');
+		insert into teams (id, name, division_id) values (1, 'Blue', 1);
+		insert into players (id, name, email, phone, password) values
+			(1, 'Placeholder', 'added@example.com', 5035551111, ''),
+			(2, 'Member', 'member@example.com', 0, 'hash');
+		insert into players_teams (player_id, team_id) values (1, 1);`)
+	panicIf(err)
+
+	ss := NewSignupService(db)
+	ctx := context.Background()
+
+	if err := ss.CreateSignup(ctx, &teamvite.Signup{Name: "Short", Email: "s@example.com", Password: "pw"}); teamvite.ErrorCode(err) != teamvite.EINVALID {
+		t.Errorf("short password: err = %v; want invalid", err)
+	}
+	if err := ss.CreateSignup(ctx, &teamvite.Signup{Name: "Member", Email: "Member@example.com", Password: "password"}); teamvite.ErrorCode(err) != teamvite.ECONFLICT {
+		t.Errorf("existing account: err = %v; want conflict", err)
+	}
+
+	// new accounts are only created once verified
+	signup := &teamvite.Signup{Name: "New", Email: " New@Example.com ", Password: "password"}
+	if err := ss.CreateSignup(ctx, signup); err != nil {
+		t.Fatal(err)
+	}
+	if players, _, _ := NewPlayerService(db).FindPlayers(ctx, teamvite.PlayerFilter{Email: "new@example.com"}); len(players) != 0 {
+		t.Errorf("player created before verification: %v", players)
+	}
+	player, err := ss.VerifySignup(ctx, signup.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if player.Email != "new@example.com" || bcrypt.CompareHashAndPassword([]byte(player.Password), []byte("password")) != nil {
+		t.Errorf("new player = %+v", player)
+	}
+	if _, err := ss.VerifySignup(ctx, signup.Token); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("verify twice: err = %v; want not found", err)
+	}
+
+	// a player added by a manager is claimed, keeping their teams and phone
+	signup = &teamvite.Signup{Name: "Claimed", Email: "added@example.com", Password: "password"}
+	if err := ss.CreateSignup(ctx, signup); err != nil {
+		t.Fatal(err)
+	}
+	player, err = ss.VerifySignup(ctx, signup.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if player.ID != 1 || player.Name != "Claimed" || player.Phone != 5035551111 || player.Password == "" {
+		t.Errorf("claimed player = %+v", player)
+	}
+
+	// phones are how text replies find their player
+	signup = &teamvite.Signup{Name: "Copycat", Email: "copy@example.com", Password: "password", Phone: 5035551111}
+	if err := ss.CreateSignup(ctx, signup); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ss.VerifySignup(ctx, signup.Token); teamvite.ErrorCode(err) != teamvite.EINVALID {
+		t.Errorf("taken phone: err = %v; want invalid", err)
+	}
+}
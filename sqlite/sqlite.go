@@ -1,6 +1,7 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"strings"
@@ -19,6 +20,32 @@ func Open(dsn string) *sql.DB {
 	// return &ql.QueryLogger{Queryer: db, Logger: log.Default()}
 }
 
+// checkAdmin returns EUNAUTHORIZED unless the user is an administrator of the
+// request's organization.
+func checkAdmin(ctx context.Context, tx *sql.Tx) error {
+	var isAdmin bool
+	err := tx.QueryRowContext(ctx,
+		"select exists(select 1 from organization_admins where organization_id = ? and player_id = ?)",
+		ownerOrganizationID(ctx), teamvite.UserIDFromContext(ctx),
+	).Scan(&isAdmin)
+	if err != nil {
+		return err
+	}
+	if !isAdmin {
+		return teamvite.Errorf(teamvite.EUNAUTHORIZED, "You must be a league administrator to do that.")
+	}
+	return nil
+}
+
+// ownerOrganizationID returns the organization new divisions and seasons
+// belong to. Outside of a request that's the default organization.
+func ownerOrganizationID(ctx context.Context) uint64 {
+	if id := teamvite.OrganizationIDFromContext(ctx); id != 0 {
+		return id
+	}
+	return teamvite.DefaultOrganizationID
+}
+
 // FormatLimitOffset returns a SQL string for a given limit & offset.
 // Clauses are only added if limit and/or offset are greater than zero.
 func FormatLimitOffset(limit, offset int) string {
@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	"github.com/benprew/teamvite"
 )
@@ -53,12 +55,39 @@ func (s *TeamService) FindTeams(ctx context.Context, filter teamvite.TeamFilter)
 }
 
 func (s *TeamService) CreateTeam(ctx context.Context, team *teamvite.Team) error {
-	tx, _ := s.db.BeginTx(ctx, nil)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// teams created by schedule imports don't have an owner
+	var ownerID sql.NullInt64
+	if userID := teamvite.UserIDFromContext(ctx); userID != 0 {
+		ownerID = sql.NullInt64{Int64: int64(userID), Valid: true}
+	}
+
+	if team.Privacy == "" {
+		team.Privacy = teamvite.TeamPublic
+	}
+	if !validPrivacy(team.Privacy) {
+		return teamvite.Errorf(teamvite.EINVALID, "Invalid privacy setting: %s", team.Privacy)
+	}
+	var archived bool
+	err = tx.QueryRowContext(ctx, "select archived from divisions where id = ?", team.DivisionID).Scan(&archived)
+	if err == sql.ErrNoRows {
+		return teamvite.Errorf(teamvite.EINVALID, "Division not found: %v", team.DivisionID)
+	} else if err != nil {
+		return err
+	}
+	if archived {
+		return teamvite.Errorf(teamvite.EINVALID, "Division %v is archived.", team.DivisionID)
+	}
 
 	result, err := tx.ExecContext(ctx, `
-			insert into teams (name, division_id) values (?, ?)
+			insert into teams (name, division_id, owner_id, privacy) values (?, ?, ?, ?)
 		`,
-		team.Name, team.DivisionID)
+		team.Name, team.DivisionID, ownerID, team.Privacy)
 	if err != nil {
 		return FormatError(err)
 	}
@@ -69,7 +98,116 @@ func (s *TeamService) CreateTeam(ctx context.Context, team *teamvite.Team) error
 		return err
 	}
 	team.ID = uint64(id)
-	return nil
+
+	if ownerID.Valid {
+		team.OwnerID = uint64(ownerID.Int64)
+		team.IsMember = true
+		_, err = tx.ExecContext(ctx,
+			"insert into players_teams (player_id, team_id, is_manager) values (?, ?, true)",
+			team.OwnerID, team.ID)
+		if err != nil {
+			return FormatError(err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *TeamService) UpdateTeam(ctx context.Context, id uint64, upd teamvite.TeamUpdate) (*teamvite.Team, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	team, err := updateTeam(ctx, tx, id, upd)
+	if err != nil {
+		return team, err
+	}
+	if err := tx.Commit(); err != nil {
+		return team, err
+	}
+	return team, nil
+}
+
+func updateTeam(ctx context.Context, tx *sql.Tx, id uint64, upd teamvite.TeamUpdate) (*teamvite.Team, error) {
+	teams, _, err := findTeams(ctx, tx, teamvite.TeamFilter{ID: id})
+	if err != nil {
+		return nil, err
+	}
+	if len(teams) == 0 {
+		return nil, teamvite.Errorf(teamvite.ENOTFOUND, "team not found: %v", id)
+	}
+	team := teams[0]
+	prev := *team
+
+	isMgr, err := isManager(ctx, tx, teamvite.UserIDFromContext(ctx), team.ID)
+	if err != nil {
+		return team, err
+	}
+	if !isMgr {
+		return team, teamvite.Errorf(teamvite.EUNAUTHORIZED, "You must be a manager to update this team.")
+	}
+
+	if v := upd.Name; v != nil {
+		team.Name = strings.TrimSpace(*v)
+	}
+	if v := upd.DivisionID; v != nil {
+		team.DivisionID = *v
+	}
+	if v := upd.Privacy; v != nil {
+		team.Privacy = *v
+	}
+
+	if team.Name == "" {
+		return &prev, teamvite.Errorf(teamvite.EINVALID, "Team name is required.")
+	}
+	if !validPrivacy(team.Privacy) {
+		return &prev, teamvite.Errorf(teamvite.EINVALID, "Invalid privacy setting: %s", team.Privacy)
+	}
+	// teams stay in their league, whichever league's host they're edited from
+	divisions, _, err := findDivisions(ctx, tx, teamvite.DivisionFilter{ID: team.DivisionID, OrganizationID: prev.OrganizationID})
+	if err != nil {
+		return &prev, err
+	}
+	if len(divisions) == 0 {
+		return &prev, teamvite.Errorf(teamvite.EINVALID, "Division not found: %v", team.DivisionID)
+	}
+	if divisions[0].Archived && team.DivisionID != prev.DivisionID {
+		return &prev, teamvite.Errorf(teamvite.EINVALID, "Division %s is archived.", divisions[0].Name)
+	}
+	team.DivisionName = divisions[0].Name
+
+	_, err = tx.ExecContext(ctx,
+		"update teams set name = ?, division_id = ?, privacy = ? where id = ?",
+		team.Name, team.DivisionID, team.Privacy, team.ID)
+	if err = FormatError(err); teamvite.ErrorCode(err) == teamvite.ECONFLICT {
+		return &prev, teamvite.Errorf(teamvite.ECONFLICT, "A team named %s already exists in division %s.", team.Name, team.DivisionName)
+	} else if err != nil {
+		return &prev, err
+	}
+
+	if team.Name != prev.Name {
+		log.Printf("renaming team %d from %s to %s", team.ID, prev.Name, team.Name)
+		_, err = tx.ExecContext(ctx, `
+			insert into teams_names (team_id, name, changed_on) values (?, ?, ?)
+			on conflict (team_id, name) do update set changed_on = excluded.changed_on`,
+			team.ID, prev.Name, time.Now())
+		if err != nil {
+			return &prev, FormatError(err)
+		}
+		team.PreviousNames = append(team.PreviousNames, prev.Name)
+	}
+
+	return team, nil
+}
+
+func validPrivacy(privacy string) bool {
+	for _, p := range teamvite.TeamPrivacies {
+		if p == privacy {
+			return true
+		}
+	}
+	return false
 }
 
 func (s *TeamService) IsManagedBy(ctx context.Context, team *teamvite.Team) bool {
@@ -97,22 +235,452 @@ func (s *TeamService) IsManagedBy(ctx context.Context, team *teamvite.Team) bool
 }
 
 func (s *TeamService) AddPlayer(ctx context.Context, team *teamvite.Team) error {
-	_, err := s.db.Exec(
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	playerID := teamvite.UserIDFromContext(ctx)
+	_, err = tx.ExecContext(ctx,
 		"insert into players_teams (player_id, team_id) values (?, ?)",
-		teamvite.UserIDFromContext(ctx),
+		playerID,
 		team.ID)
-
-	return FormatError(err)
+	if err != nil {
+		return FormatError(err)
+	}
+	if err := addToSeason(ctx, tx, team.ID, playerID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (s *TeamService) RemovePlayer(ctx context.Context, team *teamvite.Team) error {
-	_, err := s.db.Exec(
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	playerID := teamvite.UserIDFromContext(ctx)
+	if err := checkKeepsManager(ctx, tx, team.ID, playerID); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
 		"delete from players_teams where player_id = ? and team_id = ?",
-		teamvite.UserIDFromContext(ctx),
+		playerID,
 		team.ID)
+	if err != nil {
+		return FormatError(err)
+	}
+	// they didn't play the current season, earlier seasons are kept
+	_, err = tx.ExecContext(ctx, `
+		delete from players_teams_seasons
+		where team_id = ? and player_id = ? and season_id = (select season_id from teams where id = ?)`,
+		team.ID, playerID, team.ID)
+	if err != nil {
+		return FormatError(err)
+	}
+	// a transfer to or from a player who left can't be completed
+	_, err = tx.ExecContext(ctx,
+		"delete from teams_transfers where team_id = ? and (to_player_id = ? or from_player_id = ?)",
+		team.ID, playerID, playerID)
+	if err != nil {
+		return FormatError(err)
+	}
+	_, err = tx.ExecContext(ctx,
+		"delete from calendar_feeds where team_id = ? and player_id = ?",
+		team.ID, playerID)
+	if err != nil {
+		return FormatError(err)
+	}
+	return tx.Commit()
+}
+
+func (s *TeamService) SetManager(ctx context.Context, team *teamvite.Team, playerID uint64, isMgr bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	userIsMgr, err := isManager(ctx, tx, teamvite.UserIDFromContext(ctx), team.ID)
+	if err != nil {
+		return err
+	}
+	if !userIsMgr {
+		return teamvite.Errorf(teamvite.EUNAUTHORIZED, "You must be a manager to change roles.")
+	}
+	if !isMgr {
+		if err := checkKeepsManager(ctx, tx, team.ID, playerID); err != nil {
+			return err
+		}
+	}
+
+	result, err := tx.ExecContext(ctx,
+		"update players_teams set is_manager = ? where player_id = ? and team_id = ?",
+		isMgr, playerID, team.ID)
+	if err != nil {
+		return FormatError(err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return teamvite.Errorf(teamvite.ENOTFOUND, "Player %d is not on the team.", playerID)
+	}
+	log.Printf("set manager [team=%d player=%d is_manager=%t by=%d]", team.ID, playerID, isMgr, teamvite.UserIDFromContext(ctx))
+	return tx.Commit()
+}
+
+// checkKeepsManager returns an error if the team would be left without its
+// owner or without any managers once the player steps down.
+func checkKeepsManager(ctx context.Context, tx *sql.Tx, teamID, playerID uint64) error {
+	var isOwner bool
+	var otherManagers int
+	err := tx.QueryRowContext(ctx, `
+		select
+			coalesce((select owner_id = ? from teams where id = ?), false),
+			(select count(*) from players_teams where team_id = ? and player_id != ? and is_manager)`,
+		playerID, teamID, teamID, playerID).Scan(&isOwner, &otherManagers)
+	if err != nil {
+		return err
+	}
+	if isOwner {
+		return teamvite.Errorf(teamvite.EINVALID, "The team owner must transfer ownership first.")
+	}
+	mgr, err := isManager(ctx, tx, playerID, teamID)
+	if err != nil {
+		return err
+	}
+	if mgr && otherManagers == 0 {
+		return teamvite.Errorf(teamvite.EINVALID, "A team must keep at least one manager.")
+	}
+	return nil
+}
+
+func (s *TeamService) TransferOwnership(ctx context.Context, team *teamvite.Team, playerID uint64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	userID := teamvite.UserIDFromContext(ctx)
+	userIsMgr, err := isManager(ctx, tx, userID, team.ID)
+	if err != nil {
+		return err
+	}
+	if !userIsMgr || (team.OwnerID != 0 && team.OwnerID != userID) {
+		return teamvite.Errorf(teamvite.EUNAUTHORIZED, "Only the team owner can transfer ownership.")
+	}
+	if playerID == userID {
+		return teamvite.Errorf(teamvite.EINVALID, "You can't transfer a team to yourself.")
+	}
+
+	var onTeam bool
+	err = tx.QueryRowContext(ctx,
+		"select count(*) > 0 from players_teams where player_id = ? and team_id = ?",
+		playerID, team.ID).Scan(&onTeam)
+	if err != nil {
+		return err
+	}
+	if !onTeam {
+		return teamvite.Errorf(teamvite.EINVALID, "Ownership can only be transferred to a player on the team.")
+	}
+
+	// a team has at most one pending transfer, a new offer replaces it
+	_, err = tx.ExecContext(ctx, `
+		insert into teams_transfers (team_id, from_player_id, to_player_id, created_on)
+		values (?, ?, ?, ?)
+		on conflict (team_id) do update set
+			from_player_id = excluded.from_player_id,
+			to_player_id = excluded.to_player_id,
+			created_on = excluded.created_on`,
+		team.ID, userID, playerID, time.Now())
+	if err != nil {
+		return FormatError(err)
+	}
+	return tx.Commit()
+}
+
+func (s *TeamService) RespondToTransfer(ctx context.Context, team *teamvite.Team, accept bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	userID := teamvite.UserIDFromContext(ctx)
+	transfers, err := findTransfers(ctx, tx, teamvite.TransferFilter{TeamID: team.ID, ToPlayerID: userID})
+	if err != nil {
+		return err
+	}
+	if len(transfers) == 0 {
+		return teamvite.Errorf(teamvite.ENOTFOUND, "No pending transfer of %s to you.", team.Name)
+	}
+
+	if _, err := tx.ExecContext(ctx, "delete from teams_transfers where team_id = ?", team.ID); err != nil {
+		return FormatError(err)
+	}
+	if accept {
+		if _, err := tx.ExecContext(ctx, "update teams set owner_id = ? where id = ?", userID, team.ID); err != nil {
+			return FormatError(err)
+		}
+		_, err := tx.ExecContext(ctx,
+			"update players_teams set is_manager = true where player_id = ? and team_id = ?",
+			userID, team.ID)
+		if err != nil {
+			return FormatError(err)
+		}
+		team.OwnerID = userID
+		log.Printf("transferred team %d from player %d to %d", team.ID, transfers[0].FromPlayerID, userID)
+	}
+	return tx.Commit()
+}
+
+func (s *TeamService) FindTransfers(ctx context.Context, filter teamvite.TransferFilter) ([]*teamvite.Transfer, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	return findTransfers(ctx, tx, filter)
+}
+
+func findTransfers(ctx context.Context, tx *sql.Tx, filter teamvite.TransferFilter) ([]*teamvite.Transfer, error) {
+	where, args := []string{"1 = 1"}, []interface{}{}
+	if v := filter.TeamID; v != 0 {
+		where, args = append(where, "tt.team_id = ?"), append(args, v)
+	}
+	if v := filter.ToPlayerID; v != 0 {
+		where, args = append(where, "tt.to_player_id = ?"), append(args, v)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		select
+			tt.team_id, t.name, tt.from_player_id, f.name, tt.to_player_id, p.name, tt.created_on
+		from teams_transfers tt
+		join teams t on tt.team_id = t.id
+		join players f on tt.from_player_id = f.id
+		join players p on tt.to_player_id = p.id
+		where `+strings.Join(where, " and ")+`
+		order by tt.created_on`,
+		args...)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	transfers := make([]*teamvite.Transfer, 0)
+	for rows.Next() {
+		var t teamvite.Transfer
+		if err := rows.Scan(
+			&t.TeamID, &t.TeamName, &t.FromPlayerID, &t.FromPlayerName, &t.ToPlayerID, &t.ToPlayerName, &t.CreatedOn,
+		); err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, &t)
+	}
+	return transfers, rows.Err()
+}
+
+func (s *TeamService) StartSeason(ctx context.Context, team *teamvite.Team, seasonID uint64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	userID := teamvite.UserIDFromContext(ctx)
+	userIsMgr, err := isManager(ctx, tx, userID, team.ID)
+	if err != nil {
+		return err
+	}
+	if !userIsMgr {
+		return teamvite.Errorf(teamvite.EUNAUTHORIZED, "You must be a manager to start a season.")
+	}
+
+	seasons, _, err := findSeasons(ctx, tx, teamvite.SeasonFilter{ID: &seasonID})
+	if err != nil {
+		return err
+	}
+	if len(seasons) == 0 {
+		return teamvite.Errorf(teamvite.EINVALID, "Season not found: %v", seasonID)
+	}
+	if seasons[0].Closed {
+		return teamvite.Errorf(teamvite.EINVALID, "%s is closed.", seasons[0].Name)
+	}
+	var played bool
+	err = tx.QueryRowContext(ctx,
+		"select count(*) > 0 from players_teams_seasons where team_id = ? and season_id = ?",
+		team.ID, seasonID).Scan(&played)
+	if err != nil {
+		return err
+	}
+	if played || team.SeasonID == seasonID {
+		return teamvite.Errorf(teamvite.ECONFLICT, "%s already has a roster for %s.", team.Name, seasons[0].Name)
+	}
+
+	if _, err := tx.ExecContext(ctx, "update teams set season_id = ? where id = ?", seasonID, team.ID); err != nil {
+		return FormatError(err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		insert into players_teams_seasons (player_id, team_id, season_id, status)
+		select player_id, team_id, ?, case when player_id = ? then ? else ? end
+		from players_teams where team_id = ?`,
+		seasonID, userID, teamvite.MembershipActive, teamvite.MembershipPending, team.ID)
+	if err != nil {
+		return FormatError(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("team %d started season %d", team.ID, seasonID)
+	team.SeasonID, team.SeasonName = seasonID, seasons[0].Name
+	return nil
+}
+
+func (s *TeamService) RespondToSeason(ctx context.Context, team *teamvite.Team, returning bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	playerID := teamvite.UserIDFromContext(ctx)
+	var pending bool
+	err = tx.QueryRowContext(ctx, `
+		select count(*) > 0 from players_teams_seasons
+		where player_id = ? and team_id = ? and season_id = ? and status = ?`,
+		playerID, team.ID, team.SeasonID, teamvite.MembershipPending).Scan(&pending)
+	if err != nil {
+		return err
+	}
+	if !pending {
+		return teamvite.Errorf(teamvite.ENOTFOUND, "You have already responded for %s.", team.SeasonName)
+	}
+
+	status := teamvite.MembershipActive
+	if !returning {
+		status = teamvite.MembershipDeclined
+		if err := checkKeepsManager(ctx, tx, team.ID, playerID); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx,
+			"delete from players_teams where player_id = ? and team_id = ?",
+			playerID, team.ID)
+		if err != nil {
+			return FormatError(err)
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+		update players_teams_seasons set status = ?
+		where player_id = ? and team_id = ? and season_id = ?`,
+		status, playerID, team.ID, team.SeasonID)
+	if err != nil {
+		return FormatError(err)
+	}
+	return tx.Commit()
+}
+
+func (s *TeamService) FindTeamSeasons(ctx context.Context, teamID uint64) ([]*teamvite.Season, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		select s.id, s.name
+		from seasons s
+		where s.id in (select season_id from players_teams_seasons where team_id = ?)
+		order by s.id desc`,
+		teamID)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	defer rows.Close()
+
+	seasons := make([]*teamvite.Season, 0)
+	for rows.Next() {
+		var season teamvite.Season
+		if err := rows.Scan(&season.ID, &season.Name); err != nil {
+			return nil, err
+		}
+		seasons = append(seasons, &season)
+	}
+	return seasons, rows.Err()
+}
+
+func (s *TeamService) UpdateRosterPlayer(ctx context.Context, team *teamvite.Team, playerID uint64, upd teamvite.RosterUpdate) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	userIsMgr, err := isManager(ctx, tx, teamvite.UserIDFromContext(ctx), team.ID)
+	if err != nil {
+		return err
+	}
+	if !userIsMgr {
+		return teamvite.Errorf(teamvite.EUNAUTHORIZED, "You must be a manager to update the roster.")
+	}
+
+	var jersey, positions, status string
+	err = tx.QueryRowContext(ctx,
+		"select jersey, positions, roster_status from players_teams where player_id = ? and team_id = ?",
+		playerID, team.ID).Scan(&jersey, &positions, &status)
+	if err == sql.ErrNoRows {
+		return teamvite.Errorf(teamvite.ENOTFOUND, "Player %d is not on the team.", playerID)
+	} else if err != nil {
+		return err
+	}
+
+	if v := upd.Jersey; v != nil {
+		jersey = strings.TrimSpace(*v)
+	}
+	if v := upd.Positions; v != nil {
+		positions = strings.TrimSpace(*v)
+	}
+	if v := upd.Status; v != nil {
+		status = *v
+	}
+	if !teamvite.ValidJersey(jersey) {
+		return teamvite.Errorf(teamvite.EINVALID, "Jersey numbers must be up to 3 digits.")
+	}
+	if len(positions) > teamvite.MaxPositionsLength {
+		return teamvite.Errorf(teamvite.EINVALID, "Positions must be %d characters or less.", teamvite.MaxPositionsLength)
+	}
+	if status != teamvite.RosterActive && status != teamvite.RosterInjured {
+		return teamvite.Errorf(teamvite.EINVALID, "Invalid roster status: %s", status)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"update players_teams set jersey = ?, positions = ?, roster_status = ? where player_id = ? and team_id = ?",
+		jersey, positions, status, playerID, team.ID)
+	if err = FormatError(err); teamvite.ErrorCode(err) == teamvite.ECONFLICT {
+		return teamvite.Errorf(teamvite.ECONFLICT, "Another player on %s already wears #%s.", team.Name, jersey)
+	} else if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// addToSeason puts a player who joined the team on the roster for the team's
+// current season, if it has one.
+func addToSeason(ctx context.Context, tx *sql.Tx, teamID, playerID uint64) error {
+	_, err := tx.ExecContext(ctx, `
+		insert into players_teams_seasons (player_id, team_id, season_id, status)
+		select ?, id, season_id, ? from teams where id = ? and season_id is not null
+		on conflict (team_id, season_id, player_id) do update set status = excluded.status`,
+		playerID, teamvite.MembershipActive, teamID)
 	return FormatError(err)
 }
 
+// isManager returns true if the player manages the team
+func isManager(ctx context.Context, tx *sql.Tx, playerID, teamID uint64) (isMgr bool, err error) {
+	err = tx.QueryRowContext(ctx,
+		"select count(*) > 0 from players_teams where player_id = ? and team_id = ? and is_manager",
+		playerID, teamID).Scan(&isMgr)
+	return isMgr, err
+}
+
 func findTeams(ctx context.Context, tx *sql.Tx, filter teamvite.TeamFilter) (_ []*teamvite.Team, n int, err error) {
 	var teams []*teamvite.Team
 	var query string
@@ -120,19 +688,33 @@ func findTeams(ctx context.Context, tx *sql.Tx, filter teamvite.TeamFilter) (_ [
 
 	query = `
 		select
-			t.id, t.name, t.division_id
+			t.id, t.name, t.division_id, coalesce(d.name, ''), coalesce(d.organization_id, 0), coalesce(t.owner_id, 0),
+			coalesce(t.season_id, 0), coalesce(s.name, ''), t.privacy, pt.player_id is not null,
+			coalesce((select group_concat(tn.name, char(10)) from teams_names tn where tn.team_id = t.id), '')
 		from teams t
-		where 1 = 1
+		left join divisions d on t.division_id = d.id
+		left join seasons s on t.season_id = s.id
+		left join players_teams pt on pt.team_id = t.id and pt.player_id = ?
+		where (t.privacy != 'hidden' or pt.player_id is not null or exists (
+			select 1 from organization_admins oa
+			where oa.organization_id = d.organization_id and oa.player_id = ?))
 	`
+	// league administrators see hidden teams, so they can import schedules
+	args = append(args, teamvite.UserIDFromContext(ctx), teamvite.UserIDFromContext(ctx))
 
 	if filter.ID != 0 {
 		query += " and t.id = ?"
 		args = append(args, filter.ID)
+	} else if orgID := teamvite.OrganizationIDFromContext(ctx); orgID != 0 {
+		// teams are found by ID from any organization, players' pages link
+		// to their teams in every league
+		query += " and d.organization_id = ?"
+		args = append(args, orgID)
 	}
 
 	if filter.Name != nil {
-		query += " and t.name like ?"
-		args = append(args, *filter.Name)
+		query += " and (t.name like ? or t.id in (select team_id from teams_names where name like ?))"
+		args = append(args, *filter.Name, *filter.Name)
 	}
 
 	if filter.DivisionID != 0 {
@@ -140,7 +722,14 @@ func findTeams(ctx context.Context, tx *sql.Tx, filter teamvite.TeamFilter) (_ [
 		args = append(args, filter.DivisionID)
 	}
 
-	query += " order by name"
+	if filter.SeasonID != 0 {
+		query += ` and t.id in (
+			select team_id from games where season_id = ?
+			union select team_id from players_teams_seasons where season_id = ?)`
+		args = append(args, filter.SeasonID, filter.SeasonID)
+	}
+
+	query += " order by t.name"
 
 	rows, err := tx.QueryContext(ctx, query+FormatLimitOffset(filter.Limit, filter.Offset), args...)
 	if err != nil {
@@ -150,10 +739,16 @@ func findTeams(ctx context.Context, tx *sql.Tx, filter teamvite.TeamFilter) (_ [
 
 	for rows.Next() {
 		var t teamvite.Team
-		err := rows.Scan(&t.ID, &t.Name, &t.DivisionID)
+		var prevNames string
+		err := rows.Scan(
+			&t.ID, &t.Name, &t.DivisionID, &t.DivisionName, &t.OrganizationID, &t.OwnerID,
+			&t.SeasonID, &t.SeasonName, &t.Privacy, &t.IsMember, &prevNames)
 		if err != nil {
 			return nil, 0, err
 		}
+		if prevNames != "" {
+			t.PreviousNames = strings.Split(prevNames, "\n")
+		}
 		teams = append(teams, &t)
 	}
 
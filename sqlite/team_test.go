@@ -0,0 +1,272 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+
+	"github.com/benprew/teamvite"
+)
+
+func TestUpdateTeam(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into divisions (id, name) values (1, 'm1');
+		insert into teams (id, name, division_id) values (1, 'Blue', 1), (2, 'Red', 1);
+		insert into players (id, name, email) values (1, 'Manager', 'm@example.com'), (2, 'Player', 'p@example.com');
+		insert into players_teams (player_id, team_id, is_manager) values (1, 1, true), (2, 1, false);`)
+	panicIf(err)
+
+	ts := NewTeamService(db)
+	mgrCtx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 1})
+	playerCtx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 2})
+	name := func(s string) *string { return &s }
+
+	if _, err := ts.UpdateTeam(playerCtx, 1, teamvite.TeamUpdate{Name: name("Green")}); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+		t.Errorf("non-manager update: err = %v; want unauthorized", err)
+	}
+
+	if _, err := ts.UpdateTeam(mgrCtx, 1, teamvite.TeamUpdate{Name: name("Red")}); teamvite.ErrorCode(err) != teamvite.ECONFLICT {
+		t.Errorf("duplicate name: err = %v; want conflict", err)
+	}
+
+	team, err := ts.UpdateTeam(mgrCtx, 1, teamvite.TeamUpdate{Name: name("Green")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if team.Name != "Green" {
+		t.Errorf("name = %s; want Green", team.Name)
+	}
+
+	// imports still find the team by its old name
+	teams, _, err := ts.FindTeams(context.Background(), teamvite.TeamFilter{Name: name("Blue")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(teams) != 1 || teams[0].ID != 1 || len(teams[0].PreviousNames) != 1 || teams[0].PreviousNames[0] != "Blue" {
+		t.Errorf("find by previous name = %v", teams)
+	}
+}
+
+func TestUpdateTeamOtherLeague(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into organizations (id, name, host) values (2, 'Seattle Indoor', 'seattle.example.com');
+		insert into divisions (id, organization_id, name) values (1, 1, 'm1'), (2, 1, 'm2'), (3, 2, 's1');
+		insert into teams (id, name, division_id) values (1, 'Blue', 1);
+		insert into players (id, name, email) values (1, 'Manager', 'm@example.com');
+		insert into players_teams (player_id, team_id, is_manager) values (1, 1, true);`)
+	panicIf(err)
+
+	ts := NewTeamService(db)
+	// edited from the other league's host
+	ctx := teamvite.NewContextWithOrganization(context.Background(), &teamvite.Organization{ID: 2})
+	ctx = teamvite.NewContextWithUser(ctx, &teamvite.Player{ID: 1})
+	id := func(id uint64) *uint64 { return &id }
+
+	if _, err := ts.UpdateTeam(ctx, 1, teamvite.TeamUpdate{DivisionID: id(3)}); teamvite.ErrorCode(err) != teamvite.EINVALID {
+		t.Errorf("move to another league: err = %v; want invalid", err)
+	}
+	team, err := ts.UpdateTeam(ctx, 1, teamvite.TeamUpdate{DivisionID: id(2)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if team.DivisionID != 2 || team.OrganizationID != 1 {
+		t.Errorf("team = %+v; want division 2 of league 1", team)
+	}
+}
+
+func TestStartSeason(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into divisions (id, name) values (1, 'm1');
+		insert into seasons (id, name) values (1, '2026-fall'), (2, '2027-winter');
+		insert into teams (id, name, division_id, season_id) values (1, 'Blue', 1, 1);
+		insert into players (id, name, email) values (1, 'Manager', 'm@example.com'), (2, 'Returning', 'r@example.com'), (3, 'Leaving', 'l@example.com');
+		insert into players_teams (player_id, team_id, is_manager) values (1, 1, true), (2, 1, false), (3, 1, false);
+		insert into players_teams_seasons (player_id, team_id, season_id) values (1, 1, 1), (2, 1, 1), (3, 1, 1);`)
+	panicIf(err)
+
+	ts := NewTeamService(db)
+	ps := NewPlayerService(db)
+	ctx := func(id uint64) context.Context {
+		return teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: id})
+	}
+	team, err := ts.FindTeamByID(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ts.StartSeason(ctx(2), team, 2); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+		t.Errorf("non-manager start: err = %v; want unauthorized", err)
+	}
+	if err := ts.StartSeason(ctx(1), team, 1); teamvite.ErrorCode(err) != teamvite.ECONFLICT {
+		t.Errorf("restart current season: err = %v; want conflict", err)
+	}
+	if err := ts.StartSeason(ctx(1), team, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ts.RespondToSeason(ctx(2), team, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.RespondToSeason(ctx(3), team, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.RespondToSeason(ctx(3), team, true); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("second response: err = %v; want not found", err)
+	}
+
+	players, _, err := ps.FindPlayers(context.Background(), teamvite.PlayerFilter{TeamID: &team.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(players) != 2 {
+		t.Errorf("current roster = %d players; want 2", len(players))
+	}
+	for _, p := range players {
+		if p.SeasonStatus != teamvite.MembershipActive {
+			t.Errorf("player %d season status = %q; want active", p.ID, p.SeasonStatus)
+		}
+	}
+
+	// the old season's roster is kept
+	oldSeason := uint64(1)
+	players, _, err = ps.FindPlayers(context.Background(), teamvite.PlayerFilter{TeamID: &team.ID, SeasonID: &oldSeason})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(players) != 3 {
+		t.Errorf("2026-fall roster = %d players; want 3", len(players))
+	}
+}
+
+func TestUpdateRosterPlayer(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into divisions (id, name) values (1, 'm1');
+		insert into seasons (id, name) values (1, '2026-fall');
+		insert into teams (id, name, division_id) values (1, 'Blue', 1);
+		insert into players (id, name, email) values (1, 'Manager', 'm@example.com'), (2, 'Player', 'p@example.com');
+		insert into players_teams (player_id, team_id, is_manager) values (1, 1, true), (2, 1, false);
+		insert into games (id, team_id, season_id, time) values (1, 1, 1, 0);`)
+	panicIf(err)
+
+	ts := NewTeamService(db)
+	team := &teamvite.Team{ID: 1, Name: "Blue"}
+	mgrCtx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 1})
+	str := func(s string) *string { return &s }
+
+	if err := ts.UpdateRosterPlayer(mgrCtx, team, 1, teamvite.RosterUpdate{Jersey: str("00")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.UpdateRosterPlayer(mgrCtx, team, 2, teamvite.RosterUpdate{Jersey: str("00")}); teamvite.ErrorCode(err) != teamvite.ECONFLICT {
+		t.Errorf("duplicate jersey: err = %v; want conflict", err)
+	}
+	if err := ts.UpdateRosterPlayer(mgrCtx, team, 2, teamvite.RosterUpdate{Jersey: str("1234")}); teamvite.ErrorCode(err) != teamvite.EINVALID {
+		t.Errorf("long jersey: err = %v; want invalid", err)
+	}
+	if err := ts.UpdateRosterPlayer(mgrCtx, team, 2, teamvite.RosterUpdate{Status: str(teamvite.RosterInjured)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// injured reserve players aren't counted for games
+	responses, err := NewGameService(db).ResponsesForGame(context.Background(), &teamvite.Game{ID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(responses[0].Players); n != 1 {
+		t.Errorf("no reply count = %d; want 1", n)
+	}
+}
+
+func TestTeamPrivacy(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into divisions (id, name) values (1, 'm1');
+		insert into teams (id, name, division_id, privacy) values (1, 'Blue', 1, 'hidden'), (2, 'Red', 1, 'members'), (3, 'Green', 1, 'public');
+		insert into players (id, name, email) values (1, 'Member', 'm@example.com'), (2, 'Outsider', 'o@example.com');
+		insert into players_teams (player_id, team_id, is_manager) values (1, 1, true), (1, 2, true), (1, 3, true);`)
+	panicIf(err)
+
+	ts := NewTeamService(db)
+	ps := NewPlayerService(db)
+	memberCtx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 1})
+	outsiderCtx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 2})
+
+	if _, err := ts.FindTeamByID(outsiderCtx, 1); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("hidden team: err = %v; want not found", err)
+	}
+	if _, err := ts.FindTeamByID(memberCtx, 1); err != nil {
+		t.Errorf("hidden team for member: err = %v", err)
+	}
+
+	// members-only rosters aren't shown to outsiders
+	teamID := uint64(2)
+	players, _, err := ps.FindPlayers(outsiderCtx, teamvite.PlayerFilter{TeamID: &teamID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(players) != 0 {
+		t.Errorf("members-only roster = %v; want empty", players)
+	}
+
+	// public rosters are shown without contact details
+	teamID = 3
+	players, _, err = ps.FindPlayers(outsiderCtx, teamvite.PlayerFilter{TeamID: &teamID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(players) != 1 || players[0].Email != "" {
+		t.Errorf("public roster = %v; want 1 player without email", players)
+	}
+	players, _, err = ps.FindPlayers(memberCtx, teamvite.PlayerFilter{TeamID: &teamID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(players) != 1 || players[0].Email != "m@example.com" {
+		t.Errorf("roster for member = %v; want email", players)
+	}
+}
+
+func TestHiddenTeamListing(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into divisions (id, name) values (1, 'm1');
+		insert into teams (id, name, division_id, privacy) values (1, 'Blue', 1, 'hidden'), (2, 'Red', 1, 'public');
+		insert into players (id, name, email) values (1, 'Member', 'm@example.com'), (2, 'Outsider', 'o@example.com'), (3, 'Admin', 'a@example.com');
+		insert into players_teams (player_id, team_id) values (1, 1), (1, 2);
+		insert into organization_admins (organization_id, player_id) values (1, 3);`)
+	panicIf(err)
+
+	ts := NewTeamService(db)
+	ps := NewPlayerService(db)
+	member := &teamvite.Player{ID: 1}
+	for _, tc := range []struct {
+		user  *teamvite.Player
+		teams int
+	}{
+		{member, 2},
+		{&teamvite.Player{ID: 2}, 1},
+		{&teamvite.Player{ID: 3}, 2},
+	} {
+		ctx := teamvite.NewContextWithUser(teamvite.NewContextWithPlayer(context.Background(), "", member), tc.user)
+		teams, err := ps.Teams(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(teams) != tc.teams {
+			t.Errorf("player %d: member's teams = %d; want %d", tc.user.ID, len(teams), tc.teams)
+		}
+	}
+
+	// league administrators find hidden teams by name, e.g. to import schedules
+	name := "Blue"
+	adminCtx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 3})
+	if teams, _, err := ts.FindTeams(adminCtx, teamvite.TeamFilter{Name: &name}); err != nil || len(teams) != 1 {
+		t.Errorf("admin FindTeams = %v, %v; want 1 team", teams, err)
+	}
+	outsiderCtx := teamvite.NewContextWithUser(context.Background(), &teamvite.Player{ID: 2})
+	if teams, _, err := ts.FindTeams(outsiderCtx, teamvite.TeamFilter{Name: &name}); err != nil || len(teams) != 0 {
+		t.Errorf("outsider FindTeams = %v, %v; want none", teams, err)
+	}
+}
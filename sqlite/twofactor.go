@@ -0,0 +1,208 @@
+package sqlite
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/benprew/teamvite"
+	"github.com/dchest/uniuri"
+)
+
+type TwoFactorService struct {
+	db *sql.DB
+}
+
+// Ensure service implements interface.
+var _ teamvite.TwoFactorService = (*TwoFactorService)(nil)
+
+// NewTwoFactorService returns a new instance of TwoFactorService.
+func NewTwoFactorService(db *sql.DB) *TwoFactorService {
+	return &TwoFactorService{db: db}
+}
+
+func (s *TwoFactorService) FindTwoFactor(ctx context.Context, playerID uint64) (*teamvite.TwoFactor, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	tf, _, err := findTwoFactor(ctx, tx, playerID)
+	return tf, err
+}
+
+func (s *TwoFactorService) BeginTwoFactor(ctx context.Context, playerID uint64) (*teamvite.TwoFactor, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	tf, _, err := findTwoFactor(ctx, tx, playerID)
+	if err == nil {
+		if tf.Enabled {
+			return nil, teamvite.Errorf(teamvite.ECONFLICT, "Two-factor authentication is already on.")
+		}
+		return tf, nil
+	} else if teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		return nil, err
+	}
+
+	tf = &teamvite.TwoFactor{PlayerID: playerID, Secret: genTOTPSecret()}
+	_, err = tx.ExecContext(ctx,
+		"insert into two_factors (player_id, secret) values (?, ?)", tf.PlayerID, tf.Secret)
+	if err != nil {
+		return nil, FormatError(err)
+	}
+	return tf, tx.Commit()
+}
+
+func (s *TwoFactorService) EnableTwoFactor(ctx context.Context, playerID uint64, code string) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	tf, lastStep, err := findTwoFactor(ctx, tx, playerID)
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled {
+		return nil, teamvite.Errorf(teamvite.ECONFLICT, "Two-factor authentication is already on.")
+	}
+	step, ok := matchTOTP(tf.Secret, code, lastStep)
+	if !ok {
+		return nil, teamvite.Errorf(teamvite.EINVALID, "That code doesn't match, check your authenticator app and try again.")
+	}
+	if _, err := tx.ExecContext(ctx,
+		"update two_factors set enabled = true, last_step = ? where player_id = ?", step, playerID); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, teamvite.RecoveryCodeCount)
+	for i := range codes {
+		codes[i] = uniuri.NewLenChars(10, []byte("abcdefghjkmnpqrstuvwxyz23456789"))
+		if _, err := tx.ExecContext(ctx,
+			"insert into recovery_codes (player_id, code_hash) values (?, ?)", playerID, hashRecoveryCode(codes[i])); err != nil {
+			return nil, FormatError(err)
+		}
+	}
+	return codes, tx.Commit()
+}
+
+func (s *TwoFactorService) CheckTwoFactor(ctx context.Context, playerID uint64, code string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	wrong := teamvite.Errorf(teamvite.EUNAUTHORIZED, "That code doesn't match, please try again.")
+	tf, lastStep, err := findTwoFactor(ctx, tx, playerID)
+	if teamvite.ErrorCode(err) == teamvite.ENOTFOUND || (err == nil && !tf.Enabled) {
+		return wrong
+	} else if err != nil {
+		return err
+	}
+
+	if step, ok := matchTOTP(tf.Secret, code, lastStep); ok {
+		if _, err := tx.ExecContext(ctx,
+			"update two_factors set last_step = ? where player_id = ?", step, playerID); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	// not a code from the app, try it as a recovery code
+	result, err := tx.ExecContext(ctx,
+		"delete from recovery_codes where player_id = ? and code_hash = ?", playerID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return wrong
+	}
+	return tx.Commit()
+}
+
+func (s *TwoFactorService) DisableTwoFactor(ctx context.Context, playerID uint64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "delete from recovery_codes where player_id = ?", playerID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "delete from two_factors where player_id = ?", playerID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *TwoFactorService) Privileged(ctx context.Context, playerID uint64) (bool, error) {
+	var privileged bool
+	err := s.db.QueryRowContext(ctx, `
+		select exists(select 1 from players_teams where player_id = ? and is_manager)
+			or exists(select 1 from organization_admins where player_id = ?)`,
+		playerID, playerID,
+	).Scan(&privileged)
+	return privileged, err
+}
+
+// findTwoFactor also returns the step of the last code used
+func findTwoFactor(ctx context.Context, tx *sql.Tx, playerID uint64) (*teamvite.TwoFactor, int64, error) {
+	tf := teamvite.TwoFactor{PlayerID: playerID}
+	var lastStep int64
+	err := tx.QueryRowContext(ctx,
+		"select secret, enabled, last_step from two_factors where player_id = ?", playerID,
+	).Scan(&tf.Secret, &tf.Enabled, &lastStep)
+	if err == sql.ErrNoRows {
+		return nil, 0, teamvite.Errorf(teamvite.ENOTFOUND, "Two-factor authentication isn't set up.")
+	} else if err != nil {
+		return nil, 0, err
+	}
+	return &tf, lastStep, nil
+}
+
+// matchTOTP checks code against the current step and the ones either side of
+// it, allowing for clock drift. Steps up to lastStep were already used.
+func matchTOTP(secret, code string, lastStep int64) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	now := teamvite.TOTPStep(time.Now())
+	for step := now - 1; step <= now+1; step++ {
+		if step <= lastStep {
+			continue
+		}
+		want, err := teamvite.TOTPCode(secret, step)
+		if err == nil && subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// recovery codes are random, so a fast hash is enough
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
+
+func genTOTPSecret() string {
+	key := make([]byte, 20)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(key)
+}
@@ -0,0 +1,95 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benprew/teamvite"
+)
+
+func TestTwoFactor(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`insert into players (id, name, email) values (1, 'Player', 'p@example.com');`)
+	panicIf(err)
+
+	tfs := NewTwoFactorService(db)
+	ctx := context.Background()
+	if _, err := tfs.FindTwoFactor(ctx, 1); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("find before setup: err = %v; want not found", err)
+	}
+	tf, err := tfs.BeginTwoFactor(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// starting again shows the same secret until it's confirmed
+	if again, err := tfs.BeginTwoFactor(ctx, 1); err != nil || again.Secret != tf.Secret {
+		t.Errorf("begin again = %v, %v; want secret %s", again, err, tf.Secret)
+	}
+	// codes don't log in until two-factor is enabled
+	code, err := teamvite.TOTPCode(tf.Secret, teamvite.TOTPStep(time.Now()))
+	panicIf(err)
+	if err := tfs.CheckTwoFactor(ctx, 1, code); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+		t.Errorf("check before enabled: err = %v; want unauthorized", err)
+	}
+
+	if _, err := tfs.EnableTwoFactor(ctx, 1, "000000x"); teamvite.ErrorCode(err) != teamvite.EINVALID {
+		t.Errorf("enable with wrong code: err = %v; want invalid", err)
+	}
+	recovery, err := tfs.EnableTwoFactor(ctx, 1, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recovery) != teamvite.RecoveryCodeCount {
+		t.Errorf("got %d recovery codes; want %d", len(recovery), teamvite.RecoveryCodeCount)
+	}
+	if _, err := tfs.BeginTwoFactor(ctx, 1); teamvite.ErrorCode(err) != teamvite.ECONFLICT {
+		t.Errorf("begin when enabled: err = %v; want conflict", err)
+	}
+
+	// a code can't be replayed
+	if err := tfs.CheckTwoFactor(ctx, 1, code); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+		t.Errorf("replayed code: err = %v; want unauthorized", err)
+	}
+	next, err := teamvite.TOTPCode(tf.Secret, teamvite.TOTPStep(time.Now())+1)
+	panicIf(err)
+	if err := tfs.CheckTwoFactor(ctx, 1, next); err != nil {
+		t.Errorf("next code: %v", err)
+	}
+
+	// recovery codes work once
+	if err := tfs.CheckTwoFactor(ctx, 1, recovery[0]); err != nil {
+		t.Errorf("recovery code: %v", err)
+	}
+	if err := tfs.CheckTwoFactor(ctx, 1, recovery[0]); teamvite.ErrorCode(err) != teamvite.EUNAUTHORIZED {
+		t.Errorf("reused recovery code: err = %v; want unauthorized", err)
+	}
+
+	if err := tfs.DisableTwoFactor(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tfs.FindTwoFactor(ctx, 1); teamvite.ErrorCode(err) != teamvite.ENOTFOUND {
+		t.Errorf("find after disable: err = %v; want not found", err)
+	}
+}
+
+func TestTwoFactorPrivileged(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec(`
+		insert into players (id, name, email) values (1, 'Manager', 'm@example.com'), (2, 'Player', 'p@example.com'), (3, 'Admin', 'a@example.com');
+		insert into teams (id, name, division_id) values (1, 'Team', 1);
+		insert into players_teams (player_id, team_id, is_manager) values (1, 1, true), (2, 1, false);
+		insert into organization_admins (organization_id, player_id) values (1, 3);`)
+	panicIf(err)
+
+	tfs := NewTwoFactorService(db)
+	for id, want := range map[uint64]bool{1: true, 2: false, 3: true} {
+		got, err := tfs.Privileged(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Privileged(%d) = %t; want %t", id, got, want)
+		}
+	}
+}
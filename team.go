@@ -2,6 +2,7 @@ package teamvite
 
 import (
 	"context"
+	"time"
 )
 
 type Team struct {
@@ -9,6 +10,42 @@ type Team struct {
 	Name         string `db:"name,size:128" json:"name"`
 	DivisionID   uint64 `db:"division_id" json:"division_id"`
 	DivisionName string `db:"division_name" json:"division_name"`
+	OwnerID      uint64 `db:"owner_id" json:"owner_id"`
+
+	// League of the team's division
+	OrganizationID uint64 `db:"organization_id" json:"organization_id"`
+
+	// Season the team's roster is for, 0 if the team hasn't started one
+	SeasonID   uint64 `db:"season_id" json:"season_id"`
+	SeasonName string `db:"season_name" json:"season_name"`
+
+	// Who can see the team's roster, one of TeamPrivacies
+	Privacy string `db:"privacy" json:"privacy"`
+	// The user in the context is on the team
+	IsMember bool `json:"-"`
+
+	// Names the team has had before being renamed, so schedule imports that
+	// still use an old name can find the team.
+	PreviousNames []string `json:"previous_names,omitempty"`
+}
+
+// Team privacy levels. Contact details are only shown to teammates at every
+// level.
+const (
+	// Anyone can see the roster
+	TeamPublic = "public"
+	// Non-members only see the team name and schedule
+	TeamMembersOnly = "members"
+	// Only members can find the team
+	TeamHidden = "hidden"
+)
+
+var TeamPrivacies = []string{TeamPublic, TeamMembersOnly, TeamHidden}
+
+// RosterVisible returns true if the user the team was found for can see its
+// roster.
+func (t *Team) RosterVisible() bool {
+	return t.IsMember || t.Privacy == TeamPublic
 }
 
 func (t *Team) ItemID() uint64 {
@@ -20,14 +57,15 @@ func (t *Team) ItemType() string {
 }
 
 type TeamService interface {
-	// Retrieves a single Team by ID along with associated memberships. Only the
-	// Team manager & members can see a Team. Returns ENOTFOUND if Team does not
-	// exist or user does not have permission to view it.
+	// Retrieves a single Team by ID along with associated memberships. Hidden
+	// Teams can only be seen by their members. Returns ENOTFOUND if Team does
+	// not exist or user does not have permission to view it.
 	FindTeamByID(ctx context.Context, id uint64) (*Team, error)
 
-	// Retrieves a list of Teams based on a filter. Only returns Teams
-	// the user is on. Also returns a count of total matching Teams which may
-	// differ from the number of returned Teams if the "Limit" field is set.
+	// Retrieves a list of Teams based on a filter. Hidden Teams are only
+	// returned to their members. Also returns a count of total matching Teams
+	// which may differ from the number of returned Teams if the "Limit" field
+	// is set.
 	FindTeams(ctx context.Context, filter TeamFilter) ([]*Team, int, error)
 
 	// Creates a new Team and assigns the current user as the owner.  The owner
@@ -38,8 +76,9 @@ type TeamService interface {
 	// Team.  Returns the new Team state even if there was an error during update.
 	//
 	// Returns ENOTFOUND if Team does not exist. Returns EUNAUTHORIZED if user is
-	// not the Team manager.
-	// UpdateTeam(ctx context.Context, id int, upd TeamUpdate) (*Team, error)
+	// not the Team manager. Returns ECONFLICT if the division already has a
+	// Team with the new name.
+	UpdateTeam(ctx context.Context, id uint64, upd TeamUpdate) (*Team, error)
 
 	// Returns true if the current user is a manager of the Team.
 	IsManagedBy(ctx context.Context, team *Team) bool
@@ -47,16 +86,92 @@ type TeamService interface {
 	// Adds the user in the context to the team
 	AddPlayer(ctx context.Context, team *Team) error
 
-	// Removes the user in the context from the team
+	// Removes the user in the context from the team. The team's owner and
+	// last manager can't be removed.
 	RemovePlayer(ctx context.Context, team *Team) error
+
+	// Promotes a player on the team to co-manager or demotes them. Only
+	// managers can change roles, the owner can't be demoted and a team must
+	// keep at least one manager.
+	SetManager(ctx context.Context, team *Team, playerID uint64, isManager bool) error
+
+	// Offers ownership of the team to another player on the team. Only the
+	// owner (or a manager of a team without an owner) can transfer it, and
+	// the new owner must accept before anything changes.
+	TransferOwnership(ctx context.Context, team *Team, playerID uint64) error
+
+	// Accepts or declines a pending transfer offered to the user in the
+	// context. Accepting makes the user the owner and a manager of the team.
+	RespondToTransfer(ctx context.Context, team *Team, accept bool) error
+
+	// Retrieves pending ownership transfers.
+	FindTransfers(ctx context.Context, filter TransferFilter) ([]*Transfer, error)
+
+	// Makes the season the team's current season. The current roster is
+	// carried forward with each player pending until they confirm they are
+	// returning, except the manager starting the season. Only managers can
+	// start a season and returns ECONFLICT if the team already played it.
+	StartSeason(ctx context.Context, team *Team, seasonID uint64) error
+
+	// Confirms the user in the context is returning for the team's current
+	// season, or removes them from the team if not.
+	RespondToSeason(ctx context.Context, team *Team, returning bool) error
+
+	// Returns the seasons the team has a roster for, most recent first.
+	FindTeamSeasons(ctx context.Context, teamID uint64) ([]*Season, error)
+
+	// Updates a player's jersey number, positions or roster status. Only
+	// managers can update the roster. Returns ECONFLICT if another player on
+	// the team has the jersey number.
+	UpdateRosterPlayer(ctx context.Context, team *Team, playerID uint64, upd RosterUpdate) error
+}
+
+// RosterUpdate represents fields of a player's team membership to be updated
+// via UpdateRosterPlayer().
+type RosterUpdate struct {
+	Jersey    *string `json:"jersey"`
+	Positions *string `json:"positions"`
+	Status    *string `json:"roster_status"`
+}
+
+// Status of a player on a team's roster for a season
+const (
+	MembershipActive   = "active"
+	MembershipPending  = "pending" // carried forward, not yet confirmed
+	MembershipDeclined = "declined"
+)
+
+// A pending hand off of a team's ownership, waiting for the new owner to
+// accept it.
+type Transfer struct {
+	TeamID         uint64    `json:"team_id"`
+	TeamName       string    `json:"team_name"`
+	FromPlayerID   uint64    `json:"from_player_id"`
+	FromPlayerName string    `json:"from_player_name"`
+	ToPlayerID     uint64    `json:"to_player_id"`
+	ToPlayerName   string    `json:"to_player_name"`
+	CreatedOn      time.Time `json:"created_on"`
+}
+
+type TransferFilter struct {
+	TeamID     uint64 `json:"team_id"`
+	ToPlayerID uint64 `json:"to_player_id"`
+}
+
+// TeamUpdate represents a set of fields to be updated via UpdateTeam().
+type TeamUpdate struct {
+	Name       *string `json:"name"`
+	DivisionID *uint64 `json:"division_id"`
+	Privacy    *string `json:"privacy"`
 }
 
 type TeamFilter struct {
 	// Filtering fields.
 	ID           uint64  `json:"id"`
-	Name         *string `json:"name"`
+	Name         *string `json:"name"` // also matches previous names
 	DivisionID   uint64  `json:"division_id"`
 	DivisionName string  `json:"division_name"`
+	SeasonID     uint64  `json:"season_id"` // teams with a roster or games in the season
 
 	// Restrict to subset of range.
 	Offset int `json:"offset"`
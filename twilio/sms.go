@@ -0,0 +1,71 @@
+package twilio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/benprew/teamvite"
+)
+
+type SMSService struct {
+	conf   teamvite.SMSConfig
+	client *http.Client
+}
+
+// Ensure service implements interface.
+var _ teamvite.SMSService = (*SMSService)(nil)
+
+// NewSMSService returns a new instance of SMSService.
+func NewSMSService(conf teamvite.SMSConfig) *SMSService {
+	return &SMSService{conf: conf, client: &http.Client{Timeout: time.Second * 10}}
+}
+
+func (s *SMSService) SendSMS(ctx context.Context, phone int, body string) error {
+	if teamvite.Telify(phone) == "" {
+		return teamvite.Errorf(teamvite.EINVALID, "invalid phone number: %d", phone)
+	}
+	u := fmt.Sprintf("%s/Accounts/%s/Messages.json", s.conf.API, s.conf.Sid)
+
+	// HTTP requests to the API are protected with HTTP Basic
+	// authentication. To learn more about how Twilio handles authentication,
+	// please refer to our security documentation.
+	//
+	// In short, you will use your Twilio Account SID as the username and your
+	// Auth Token as the password for HTTP Basic authentication with Twilio.
+	//
+	// curl -G https://api.twilio.com/2010-04-01/Accounts \
+	//   -u <YOUR_ACCOUNT_SID>:<YOUR_AUTH_TOKEN>
+	postParams := url.Values{}
+	postParams.Add("To", fmt.Sprintf("+1%d", phone))
+	postParams.Add("From", s.conf.From)
+	postParams.Add("Body", body)
+	postParams.Add("StatusCallback", "https://www.teamvite.com/sms")
+
+	req, err := http.NewRequestWithContext(ctx, "POST", u, strings.NewReader(postParams.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(s.conf.Sid, s.conf.Token)
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	log.Println("SMS gateway response:", string(respBody))
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("SMS gateway returned %s", resp.Status)
+	}
+	return nil
+}
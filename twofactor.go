@@ -0,0 +1,89 @@
+package teamvite
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TwoFactor is a player's authenticator app (TOTP) secret. It isn't Enabled,
+// or needed to log in, until the player confirms a code from the app.
+type TwoFactor struct {
+	PlayerID uint64 `json:"player_id"`
+	Secret   string `json:"-"` // base32, as authenticator apps expect
+	Enabled  bool   `json:"enabled"`
+}
+
+// Number of single-use recovery codes given out when two-factor is enabled
+const RecoveryCodeCount = 10
+
+// How long a player has to enter their code after their password
+const TwoFactorLoginLength = time.Minute * 10
+
+type TwoFactorService interface {
+	// Retrieves the player's two-factor secret. Returns ENOTFOUND if they
+	// haven't started setting it up.
+	FindTwoFactor(ctx context.Context, playerID uint64) (*TwoFactor, error)
+
+	// Returns the player's unconfirmed secret, creating one if needed.
+	// Returns ECONFLICT if two-factor is already enabled.
+	BeginTwoFactor(ctx context.Context, playerID uint64) (*TwoFactor, error)
+
+	// Enables two-factor once code matches the secret, returning new
+	// recovery codes. Returns EINVALID if the code is wrong.
+	EnableTwoFactor(ctx context.Context, playerID uint64, code string) ([]string, error)
+
+	// Checks a code from the player's app or uses up one of their recovery
+	// codes. Returns EUNAUTHORIZED if it doesn't match.
+	CheckTwoFactor(ctx context.Context, playerID uint64, code string) error
+
+	// Turns two-factor off and removes the secret and recovery codes.
+	DisableTwoFactor(ctx context.Context, playerID uint64) error
+
+	// Reports whether the player manages a team or administers a league,
+	// the players RequireTwoFactor applies to.
+	Privileged(ctx context.Context, playerID uint64) (bool, error)
+}
+
+// TOTP (RFC 6238) codes change every period
+const totpPeriod = 30
+
+// TOTPStep returns the time step, the counter codes are generated from, at t.
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// TOTPCode returns the 6 digit code for the secret at step.
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(
+		strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", err
+	}
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0xf
+	n := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", n%1000000), nil
+}
+
+// TOTPURL returns the otpauth:// URL authenticator apps read from a QR code.
+func TOTPURL(secret, issuer, account string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	v.Set("period", fmt.Sprint(totpPeriod))
+	return fmt.Sprintf("otpauth://totp/%s:%s?%s",
+		url.PathEscape(issuer), url.PathEscape(account), v.Encode())
+}
@@ -0,0 +1,41 @@
+package teamvite
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 SHA1 test vectors, truncated to 6 digits
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" // "12345678901234567890"
+	tests := []struct {
+		time int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		code, err := TOTPCode(secret, TOTPStep(time.Unix(tt.time, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tt.code {
+			t.Errorf("TOTPCode at %d = %s; want %s", tt.time, code, tt.code)
+		}
+	}
+
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Errorf("TOTPCode with a bad secret: no error")
+	}
+}
+
+func TestTOTPURL(t *testing.T) {
+	u := TOTPURL("ABC", "My League", "p@example.com")
+	if !strings.HasPrefix(u, "otpauth://totp/My%20League:p@example.com?") || !strings.Contains(u, "secret=ABC") {
+		t.Errorf("TOTPURL = %s", u)
+	}
+}
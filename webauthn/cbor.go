@@ -0,0 +1,123 @@
+package webauthn
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Just enough CBOR (RFC 8949) to read attestation objects and COSE keys:
+// definite length integers, byte and text strings, arrays, maps and simple
+// values. Map keys are int64 or string.
+
+var errCBOR = errors.New("webauthn: invalid CBOR")
+
+// maximum nesting, attestation objects and keys are shallow
+const cborMaxDepth = 8
+
+// decodeCBOR decodes the first item in data, returning it and the number of
+// bytes it took.
+func decodeCBOR(data []byte) (interface{}, int, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (interface{}, int, error) {
+	if depth > cborMaxDepth || len(data) == 0 {
+		return nil, 0, errCBOR
+	}
+	major := data[0] >> 5
+	arg, n, err := cborArgument(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch major {
+	case 0: // unsigned int
+		if arg > math.MaxInt64 {
+			return nil, 0, errCBOR
+		}
+		return int64(arg), n, nil
+	case 1: // negative int
+		if arg > math.MaxInt64 {
+			return nil, 0, errCBOR
+		}
+		return -1 - int64(arg), n, nil
+	case 2, 3: // byte and text strings
+		if arg > uint64(len(data)-n) {
+			return nil, 0, errCBOR
+		}
+		b := data[n : n+int(arg)]
+		if major == 3 {
+			return string(b), n + int(arg), nil
+		}
+		return b, n + int(arg), nil
+	case 4: // array
+		if arg > uint64(len(data)) {
+			return nil, 0, errCBOR
+		}
+		items := make([]interface{}, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			item, m, err := decodeCBORItem(data[n:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, item)
+			n += m
+		}
+		return items, n, nil
+	case 5: // map
+		if arg > uint64(len(data)) {
+			return nil, 0, errCBOR
+		}
+		m := make(map[interface{}]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			key, kn, err := decodeCBORItem(data[n:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, 0, errCBOR
+			}
+			n += kn
+			value, vn, err := decodeCBORItem(data[n:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			n += vn
+			m[key] = value
+		}
+		return m, n, nil
+	case 7: // simple values
+		switch data[0] & 0x1f {
+		case 20:
+			return false, 1, nil
+		case 21:
+			return true, 1, nil
+		case 22, 23:
+			return nil, 1, nil
+		}
+	}
+	// tags, floats and indefinite lengths aren't used by WebAuthn
+	return nil, 0, errCBOR
+}
+
+// cborArgument reads the argument of the item's head, returning it and the
+// length of the head.
+func cborArgument(data []byte) (uint64, int, error) {
+	info := data[0] & 0x1f
+	switch {
+	case info < 24:
+		return uint64(info), 1, nil
+	case info == 24 && len(data) >= 2:
+		return uint64(data[1]), 2, nil
+	case info == 25 && len(data) >= 3:
+		return uint64(binary.BigEndian.Uint16(data[1:])), 3, nil
+	case info == 26 && len(data) >= 5:
+		return uint64(binary.BigEndian.Uint32(data[1:])), 5, nil
+	case info == 27 && len(data) >= 9:
+		return binary.BigEndian.Uint64(data[1:]), 9, nil
+	}
+	return 0, 0, errCBOR
+}
@@ -0,0 +1,316 @@
+// Package webauthn verifies passkey registrations and logins (WebAuthn Level
+// 2). It's a minimal relying party: attestation statements aren't checked, so
+// any authenticator is trusted, and only ES256, RS256 and EdDSA keys are
+// supported, which covers the platform authenticators passkeys use.
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// authenticator data flags
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttested     = 0x40
+)
+
+// COSE algorithms
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+// Algorithms lists the supported COSE algorithms, most preferred first, for
+// the pubKeyCredParams of the registration options.
+var Algorithms = []int{AlgES256, AlgEdDSA, AlgRS256}
+
+// RelyingParty is the site credentials are registered with.
+type RelyingParty struct {
+	// Domain the credentials are scoped to, ex. teamvite.com
+	ID string
+	// Origin the browser reports, ex. https://teamvite.com
+	Origin string
+}
+
+// Credential is a registered authenticator's key.
+type Credential struct {
+	ID        []byte
+	PublicKey []byte // COSE_Key
+	SignCount uint32
+}
+
+// Registration is what navigator.credentials.create returns, base64url
+// encoded by the page.
+type Registration struct {
+	ClientDataJSON    string `json:"client_data_json"`
+	AttestationObject string `json:"attestation_object"`
+}
+
+// Assertion is what navigator.credentials.get returns, base64url encoded by
+// the page.
+type Assertion struct {
+	CredentialID      string `json:"credential_id"`
+	ClientDataJSON    string `json:"client_data_json"`
+	AuthenticatorData string `json:"authenticator_data"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"user_handle"`
+}
+
+var ErrVerification = errors.New("webauthn: verification failed")
+
+// NewChallenge returns a random challenge for one ceremony, base64url
+// encoded as it's sent to the browser.
+func NewChallenge() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return Encode(b)
+}
+
+// Encode base64url encodes b without padding, as WebAuthn does.
+func Encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode decodes unpadded base64url.
+func Decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type authenticatorData struct {
+	RPIDHash   []byte
+	Flags      byte
+	SignCount  uint32
+	Credential *Credential // only when registering
+}
+
+// VerifyRegistration checks a new credential was made for challenge on this
+// relying party with the user verified, and returns it.
+func (rp RelyingParty) VerifyRegistration(reg Registration, challenge string) (*Credential, error) {
+	clientDataJSON, err := Decode(reg.ClientDataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("%w: client data: %s", ErrVerification, err)
+	}
+	if err := rp.checkClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	attestation, err := Decode(reg.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: attestation object: %s", ErrVerification, err)
+	}
+	obj, _, err := decodeCBOR(attestation)
+	if err != nil {
+		return nil, fmt.Errorf("%w: attestation object: %s", ErrVerification, err)
+	}
+	m, _ := obj.(map[interface{}]interface{})
+	rawAuthData, _ := m["authData"].([]byte)
+	if rawAuthData == nil {
+		return nil, fmt.Errorf("%w: attestation object has no authData", ErrVerification)
+	}
+
+	authData, err := rp.checkAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.Credential == nil {
+		return nil, fmt.Errorf("%w: no credential in authenticator data", ErrVerification)
+	}
+	if _, err := parsePublicKey(authData.Credential.PublicKey); err != nil {
+		return nil, err
+	}
+	authData.Credential.SignCount = authData.SignCount
+	return authData.Credential, nil
+}
+
+// VerifyAssertion checks the assertion was signed by cred for challenge on
+// this relying party with the user verified. It returns the authenticator's
+// new signature count, which should be stored with the credential.
+func (rp RelyingParty) VerifyAssertion(a Assertion, challenge string, cred *Credential) (uint32, error) {
+	clientDataJSON, err := Decode(a.ClientDataJSON)
+	if err != nil {
+		return 0, fmt.Errorf("%w: client data: %s", ErrVerification, err)
+	}
+	if err := rp.checkClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+	rawAuthData, err := Decode(a.AuthenticatorData)
+	if err != nil {
+		return 0, fmt.Errorf("%w: authenticator data: %s", ErrVerification, err)
+	}
+	authData, err := rp.checkAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+	sig, err := Decode(a.Signature)
+	if err != nil {
+		return 0, fmt.Errorf("%w: signature: %s", ErrVerification, err)
+	}
+
+	key, err := parsePublicKey(cred.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if !key.verify(signed, sig) {
+		return 0, fmt.Errorf("%w: bad signature", ErrVerification)
+	}
+
+	// authenticators that count signatures always increase it, so a count
+	// that doesn't means the credential was cloned
+	if (authData.SignCount != 0 || cred.SignCount != 0) && authData.SignCount <= cred.SignCount {
+		return 0, fmt.Errorf("%w: signature count went from %d to %d", ErrVerification, cred.SignCount, authData.SignCount)
+	}
+	return authData.SignCount, nil
+}
+
+func (rp RelyingParty) checkClientData(raw []byte, typ, challenge string) error {
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return fmt.Errorf("%w: client data: %s", ErrVerification, err)
+	}
+	if cd.Type != typ {
+		return fmt.Errorf("%w: client data type %q, want %q", ErrVerification, cd.Type, typ)
+	}
+	if challenge == "" || subtle.ConstantTimeCompare([]byte(cd.Challenge), []byte(challenge)) != 1 {
+		return fmt.Errorf("%w: challenge doesn't match", ErrVerification)
+	}
+	if cd.Origin != rp.Origin {
+		return fmt.Errorf("%w: origin %q, want %q", ErrVerification, cd.Origin, rp.Origin)
+	}
+	return nil
+}
+
+func (rp RelyingParty) checkAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	authData, err := parseAuthenticatorData(raw)
+	if err != nil {
+		return nil, err
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(authData.RPIDHash, rpIDHash[:]) {
+		return nil, fmt.Errorf("%w: credential is for another site", ErrVerification)
+	}
+	if authData.Flags&flagUserPresent == 0 || authData.Flags&flagUserVerified == 0 {
+		return nil, fmt.Errorf("%w: user wasn't verified", ErrVerification)
+	}
+	return authData, nil
+}
+
+func parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, fmt.Errorf("%w: authenticator data too short", ErrVerification)
+	}
+	authData := authenticatorData{
+		RPIDHash:  raw[:32],
+		Flags:     raw[32],
+		SignCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	if authData.Flags&flagAttested == 0 {
+		return &authData, nil
+	}
+
+	// attested credential data: aaguid (16), id length (2), id, COSE key
+	rest := raw[37:]
+	if len(rest) < 18 {
+		return nil, fmt.Errorf("%w: attested credential data too short", ErrVerification)
+	}
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if len(rest) < idLen {
+		return nil, fmt.Errorf("%w: credential id too short", ErrVerification)
+	}
+	cred := Credential{ID: rest[:idLen]}
+	_, n, err := decodeCBOR(rest[idLen:])
+	if err != nil {
+		return nil, fmt.Errorf("%w: credential public key: %s", ErrVerification, err)
+	}
+	cred.PublicKey = rest[idLen : idLen+n]
+	authData.Credential = &cred
+	return &authData, nil
+}
+
+type publicKey struct {
+	alg int64
+	key crypto.PublicKey
+}
+
+func (k publicKey) verify(signed, sig []byte) bool {
+	switch key := k.key.(type) {
+	case *ecdsa.PublicKey:
+		hash := sha256.Sum256(signed)
+		return ecdsa.VerifyASN1(key, hash[:], sig)
+	case *rsa.PublicKey:
+		hash := sha256.Sum256(signed)
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, hash[:], sig) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, signed, sig)
+	}
+	return false
+}
+
+// parsePublicKey reads a COSE_Key (RFC 9053)
+func parsePublicKey(raw []byte) (publicKey, error) {
+	obj, _, err := decodeCBOR(raw)
+	if err != nil {
+		return publicKey{}, fmt.Errorf("%w: public key: %s", ErrVerification, err)
+	}
+	m, _ := obj.(map[interface{}]interface{})
+	kty, _ := m[int64(1)].(int64)
+	alg, _ := m[int64(3)].(int64)
+	unsupported := fmt.Errorf("%w: unsupported key type %d, algorithm %d", ErrVerification, kty, alg)
+
+	switch {
+	case kty == 2 && alg == AlgES256:
+		crv, _ := m[int64(-1)].(int64)
+		x, _ := m[int64(-2)].([]byte)
+		y, _ := m[int64(-3)].([]byte)
+		if crv != 1 || len(x) != 32 || len(y) != 32 {
+			return publicKey{}, unsupported
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return publicKey{}, fmt.Errorf("%w: public key isn't on its curve", ErrVerification)
+		}
+		return publicKey{alg: alg, key: key}, nil
+	case kty == 3 && alg == AlgRS256:
+		n, _ := m[int64(-1)].([]byte)
+		e, _ := m[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return publicKey{}, unsupported
+		}
+		exp := new(big.Int).SetBytes(e)
+		return publicKey{alg: alg, key: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}}, nil
+	case kty == 1 && alg == AlgEdDSA:
+		crv, _ := m[int64(-1)].(int64)
+		x, _ := m[int64(-2)].([]byte)
+		if crv != 6 || len(x) != ed25519.PublicKeySize {
+			return publicKey{}, unsupported
+		}
+		return publicKey{alg: alg, key: ed25519.PublicKey(x)}, nil
+	}
+	return publicKey{}, unsupported
+}
@@ -0,0 +1,290 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+var testRP = RelyingParty{ID: "teamvite.com", Origin: "https://teamvite.com"}
+
+// softAuthenticator is a software passkey, standing in for a browser and
+// platform authenticator.
+type softAuthenticator struct {
+	rpID   string
+	origin string
+	flags  byte
+	count  uint32
+	credID []byte
+	ec     *ecdsa.PrivateKey
+	ed     ed25519.PrivateKey
+}
+
+func newSoftAuthenticator(t *testing.T, rp RelyingParty) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &softAuthenticator{
+		rpID:   rp.ID,
+		origin: rp.Origin,
+		flags:  flagUserPresent | flagUserVerified,
+		credID: []byte("credential-1"),
+		ec:     key,
+	}
+}
+
+func (a *softAuthenticator) coseKey() []byte {
+	if a.ed != nil {
+		return cborMap{{int64(1), int64(1)}, {int64(3), int64(AlgEdDSA)}, {int64(-1), int64(6)},
+			{int64(-2), []byte(a.ed.Public().(ed25519.PublicKey))}}.encode()
+	}
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.ec.X.FillBytes(x)
+	a.ec.Y.FillBytes(y)
+	return cborMap{{int64(1), int64(2)}, {int64(3), int64(AlgES256)}, {int64(-1), int64(1)},
+		{int64(-2), x}, {int64(-3), y}}.encode()
+}
+
+func (a *softAuthenticator) authData(flags byte, attested []byte) []byte {
+	hash := sha256.Sum256([]byte(a.rpID))
+	data := append(hash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.count)
+	return append(data, attested...)
+}
+
+func (a *softAuthenticator) clientData(typ, challenge string) []byte {
+	b, _ := json.Marshal(clientData{Type: typ, Challenge: challenge, Origin: a.origin})
+	return b
+}
+
+func (a *softAuthenticator) create(challenge string) Registration {
+	attested := make([]byte, 16) // aaguid
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credID)))
+	attested = append(append(attested, a.credID...), a.coseKey()...)
+	att := cborMap{
+		{"fmt", "none"},
+		{"attStmt", cborMap{}},
+		{"authData", a.authData(a.flags|flagAttested, attested)},
+	}
+	return Registration{
+		ClientDataJSON:    Encode(a.clientData("webauthn.create", challenge)),
+		AttestationObject: Encode(att.encode()),
+	}
+}
+
+func (a *softAuthenticator) get(challenge string) Assertion {
+	a.count++
+	authData := a.authData(a.flags, nil)
+	clientDataJSON := a.clientData("webauthn.get", challenge)
+	hash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, authData...), hash[:]...)
+
+	var sig []byte
+	if a.ed != nil {
+		sig = ed25519.Sign(a.ed, signed)
+	} else {
+		digest := sha256.Sum256(signed)
+		var err error
+		if sig, err = ecdsa.SignASN1(rand.Reader, a.ec, digest[:]); err != nil {
+			panic(err)
+		}
+	}
+	return Assertion{
+		CredentialID:      Encode(a.credID),
+		ClientDataJSON:    Encode(clientDataJSON),
+		AuthenticatorData: Encode(authData),
+		Signature:         Encode(sig),
+	}
+}
+
+func TestRegisterAndLogIn(t *testing.T) {
+	auth := newSoftAuthenticator(t, testRP)
+	challenge := NewChallenge()
+	cred, err := testRP.VerifyRegistration(auth.create(challenge), challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(cred.ID) != "credential-1" {
+		t.Errorf("credential id = %q", cred.ID)
+	}
+
+	for i := 1; i <= 2; i++ {
+		challenge = NewChallenge()
+		count, err := testRP.VerifyAssertion(auth.get(challenge), challenge, cred)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != uint32(i) {
+			t.Errorf("sign count = %d; want %d", count, i)
+		}
+		cred.SignCount = count
+	}
+}
+
+func TestEdDSA(t *testing.T) {
+	auth := newSoftAuthenticator(t, testRP)
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth.ed = key
+
+	challenge := NewChallenge()
+	cred, err := testRP.VerifyRegistration(auth.create(challenge), challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge = NewChallenge()
+	if _, err := testRP.VerifyAssertion(auth.get(challenge), challenge, cred); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRegistrationRejected(t *testing.T) {
+	tests := map[string]func(a *softAuthenticator) (Registration, string){
+		"wrong challenge": func(a *softAuthenticator) (Registration, string) {
+			return a.create(NewChallenge()), NewChallenge()
+		},
+		"wrong origin": func(a *softAuthenticator) (Registration, string) {
+			a.origin = "https://evil.example"
+			c := NewChallenge()
+			return a.create(c), c
+		},
+		"other site": func(a *softAuthenticator) (Registration, string) {
+			a.rpID = "evil.example"
+			c := NewChallenge()
+			return a.create(c), c
+		},
+		"user not verified": func(a *softAuthenticator) (Registration, string) {
+			a.flags = flagUserPresent
+			c := NewChallenge()
+			return a.create(c), c
+		},
+		"login instead of registration": func(a *softAuthenticator) (Registration, string) {
+			c := NewChallenge()
+			reg := a.create(c)
+			reg.ClientDataJSON = Encode(a.clientData("webauthn.get", c))
+			return reg, c
+		},
+		"garbage attestation": func(a *softAuthenticator) (Registration, string) {
+			c := NewChallenge()
+			reg := a.create(c)
+			reg.AttestationObject = Encode([]byte{0xa1, 0x63})
+			return reg, c
+		},
+	}
+	for name, build := range tests {
+		reg, challenge := build(newSoftAuthenticator(t, testRP))
+		if _, err := testRP.VerifyRegistration(reg, challenge); !errors.Is(err, ErrVerification) {
+			t.Errorf("%s: err = %v; want verification error", name, err)
+		}
+	}
+}
+
+func TestAssertionRejected(t *testing.T) {
+	auth := newSoftAuthenticator(t, testRP)
+	challenge := NewChallenge()
+	cred, err := testRP.VerifyRegistration(auth.create(challenge), challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	challenge = NewChallenge()
+	if _, err := testRP.VerifyAssertion(auth.get(challenge), NewChallenge(), cred); !errors.Is(err, ErrVerification) {
+		t.Errorf("wrong challenge: err = %v", err)
+	}
+
+	// another authenticator's signature
+	other := newSoftAuthenticator(t, testRP)
+	challenge = NewChallenge()
+	if _, err := testRP.VerifyAssertion(other.get(challenge), challenge, cred); !errors.Is(err, ErrVerification) {
+		t.Errorf("other key: err = %v", err)
+	}
+
+	// a replayed or cloned authenticator's count doesn't go up
+	challenge = NewChallenge()
+	count, err := testRP.VerifyAssertion(auth.get(challenge), challenge, cred)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred.SignCount = count + 5
+	challenge = NewChallenge()
+	if _, err := testRP.VerifyAssertion(auth.get(challenge), challenge, cred); !errors.Is(err, ErrVerification) {
+		t.Errorf("sign count went backwards: err = %v", err)
+	}
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	m := cborMap{{"a", int64(-500)}, {int64(2), []byte("xyz")}, {"t", true}}.encode()
+	v, n, err := decodeCBOR(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(m) {
+		t.Errorf("decoded %d of %d bytes", n, len(m))
+	}
+	got := v.(map[interface{}]interface{})
+	if got["a"] != int64(-500) || string(got[int64(2)].([]byte)) != "xyz" || got["t"] != true {
+		t.Errorf("decoded %v", got)
+	}
+
+	for i := 0; i < len(m); i++ {
+		if _, _, err := decodeCBOR(m[:i]); err == nil {
+			t.Errorf("truncated to %d bytes: no error", i)
+		}
+	}
+}
+
+// cborMap encodes as a CBOR map in order, so tests control the bytes
+type cborMap [][2]interface{}
+
+func (m cborMap) encode() []byte {
+	b := cborHead(5, uint64(len(m)))
+	for _, kv := range m {
+		b = append(b, cborEncode(kv[0])...)
+		b = append(b, cborEncode(kv[1])...)
+	}
+	return b
+}
+
+func cborEncode(v interface{}) []byte {
+	switch v := v.(type) {
+	case int64:
+		if v < 0 {
+			return cborHead(1, uint64(-1-v))
+		}
+		return cborHead(0, uint64(v))
+	case []byte:
+		return append(cborHead(2, uint64(len(v))), v...)
+	case string:
+		return append(cborHead(3, uint64(len(v))), v...)
+	case bool:
+		if v {
+			return []byte{0xf5}
+		}
+		return []byte{0xf4}
+	case cborMap:
+		return v.encode()
+	}
+	panic("can't encode")
+}
+
+func cborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n < 1<<8:
+		return []byte{major<<5 | 24, byte(n)}
+	case n < 1<<16:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+	}
+	return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+}